@@ -0,0 +1,90 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// AcqConfig describes an acquisition as a whole, so that it can be checked
+// with Validate before anything is sent to the BitScope.
+type AcqConfig struct {
+	// Model of the BitScope ("bs10" or "bs05"), as in Scope.Model
+	Model string
+	// Analog channels, 'a' and/or 'b'
+	Channels []byte
+	// Sample rate in samples per second
+	SampleRate float64
+	// Samples before and after the trigger, per channel
+	Pre, Post uint
+	// Vertical range, as for Vertical ("5.2v", "520mv")
+	Range string
+	// Remove the mean in the conversion to volts (see Scope.SoftwareAC)
+	SoftwareAC bool
+	Trigger    TriggerSpec
+}
+
+// TriggerSpec describes the trigger of an acquisition.
+type TriggerSpec struct {
+	// Channel of the trigger, 'a' or 'b'
+	Channel byte
+	// Trigger level, in volts
+	Level float64
+}
+
+// Validate checks the configuration against the limits of the model, and
+// returns an error that describes the first problem found.
+func (c *AcqConfig) Validate() error {
+
+	if c.Model != "bs10" && c.Model != "bs05" {
+		return fmt.Errorf("Unsupported model %q", c.Model)
+	}
+
+	if len(c.Channels) == 0 || len(c.Channels) > 2 {
+		return errors.New("One or two channels must be selected")
+	}
+	seen := map[int]bool{}
+	for _, ch := range c.Channels {
+		i, err := channel(uint(ch))
+		if err != nil {
+			return fmt.Errorf("Unknown channel %q", ch)
+		}
+		if seen[i] {
+			return fmt.Errorf("Channel %q selected twice", ch)
+		}
+		seen[i] = true
+	}
+
+	min := masterClock / maxTicks
+	max := masterClock / minTicks
+	if c.SampleRate < min || c.SampleRate > max {
+		return fmt.Errorf("Sample rate %g out of range (%g to %g)", c.SampleRate, min, max)
+	}
+
+	// Channels share the buffer
+	n := c.Pre + c.Post
+	limit := uint(bufferSize / len(c.Channels))
+	if n == 0 {
+		return errors.New("No samples to capture")
+	}
+	if n > limit {
+		return fmt.Errorf("Record of %d samples longer than the buffer (%d samples per channel)", n, limit)
+	}
+
+	_, _, r, err := vertical(c.Model, c.Range)
+	if err != nil {
+		return fmt.Errorf("Range %q: %v", c.Range, err)
+	}
+
+	i, err := channel(uint(c.Trigger.Channel))
+	if err != nil || !seen[i] {
+		return fmt.Errorf("Trigger channel %q is not a captured channel", c.Trigger.Channel)
+	}
+	if math.Abs(c.Trigger.Level) > r/2 {
+		return fmt.Errorf("Trigger level %g V outside the range of ±%g V", c.Trigger.Level, r/2)
+	}
+
+	return nil
+}
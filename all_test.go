@@ -3,10 +3,17 @@
 package bitscope
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"strings"
+	"time"
 	// "testing"
+
+	"bitscope/trigger"
 )
 
 func ExampleScope_Id() {
@@ -74,3 +81,1007 @@ func Example() {
 	}
 	println("")
 }
+
+func ExampleNewMock() {
+	bs, err := New(NewMock("bs05"))
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer bs.Close()
+
+	fmt.Println(bs.ID, bs.Model)
+	// Output: BS000501 bs05
+}
+
+func ExampleReplay() {
+	var rec bytes.Buffer
+
+	bs, _ := New(Record(NewMock("bs10"), &rec))
+	bs.Vertical("2v")
+	bs.Close()
+
+	// Replay the session: the same commands get the same responses
+	r, _ := Replay(&rec)
+	bs, _ = New(r)
+	fmt.Println(bs.ID, bs.Vertical("2v"))
+	// Output: BS001001 <nil>
+}
+
+func ExampleScope_Dump() {
+	bs, err := New(NewMock("bs10"))
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer bs.Close()
+
+	bs.Trace(0, 256, 0)
+
+	b, err := bs.Dump(256)
+	fmt.Println(len(b), err)
+	// Output: 256 <nil>
+}
+
+func ExampleBatch() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	err := bs.Batch().Set(0x22, 0x1234, 2).Cmd("[7b]@[80]s").Flush()
+	fmt.Printf("%02x %02x %02x %v\n", m.Reg(0x22), m.Reg(0x23), m.Reg(0x7b), err)
+	// Output: 34 12 80 <nil>
+}
+
+func ExampleScope_ReadReg() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	bs.WriteReg(RegTraceDelay, 123456)
+	fmt.Println(bs.ReadReg(RegTraceDelay))
+	// Output: 123456 <nil>
+}
+
+func ExampleScope_DumpRegisters() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	bs.Trace(0, 256, 0)
+
+	regs, err := bs.DumpRegisters()
+	fmt.Println(RegTraceOutro, regs[RegTraceOutro], err)
+	// Output: TraceOutro 256 <nil>
+}
+
+func ExampleScope_Raw() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	b, err := bs.Raw([]byte("[7b]@[80]sp"), ResponseSpec{Lines: 1})
+	fmt.Printf("%q %v\n", b, err)
+	// Output: "\r80\r" <nil>
+}
+
+func ExampleScope_SaveState() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.Horizontal(1, 40)
+	s, _ := bs.SaveState()
+
+	// Restore the time base after a reset
+	bs.Reset()
+	err := bs.RestoreState(s)
+
+	t, _ := bs.SaveState()
+	fmt.Println(t.Registers[RegClockTicks], s.Diff(t), err)
+
+	// The status and the LEDs are not part of the state
+	_, ok := s.Registers[RegSampleCounter]
+	s.Registers[RegLedRed] = 0xff
+	err = bs.RestoreState(s)
+	fmt.Println(ok, m.Reg(byte(RegLedRed)), err)
+	// Output:
+	// 40 [] <nil>
+	// false 0 <nil>
+}
+
+func ExampleScope_SetProtocolTrace() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	var b bytes.Buffer
+	bs.SetProtocolTrace(&b)
+	bs.Horizontal(1, 40)
+
+	var e ProtocolEntry
+	json.Unmarshal(b.Bytes(), &e)
+	fmt.Println(e.Cmd, e.Writes)
+	// Output: 14@01z00s2e@28z00s [{ClockScale 1} {ClockTicks 40}]
+}
+
+func ExampleScope_SetDumpFormat() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	bs.Trace(0, 256, 0)
+	bin, _ := bs.Dump(256)
+
+	bs.SetDumpFormat(DumpHex)
+	txt, err := bs.Dump(256)
+	fmt.Println(bytes.Equal(bin, txt), err)
+	// Output: true <nil>
+}
+
+func ExampleScope_DualDump() {
+	m := NewMock("bs10")
+	m.Signal = func(i int) byte { return 10 }
+	m.SignalB = func(i int) byte { return 20 }
+
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.DualTrace(0, 128, 0)
+	a, b, err := bs.DualDump(4)
+	fmt.Println(a, b, err)
+	// Output: [10 10 10 10] [20 20 20 20] <nil>
+}
+
+func ExampleScope_LogicDump() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	bs.Horizontal(1, 40)
+	bs.LogicTrace(0, 256, 0)
+
+	// The mock logic inputs count up
+	l, err := bs.LogicDump(4)
+	fmt.Println(l.Rate, l.Raw, l.Channels[0], err)
+	// Output: 1e+06 [0 1 2 3] [false true false true] <nil>
+}
+
+func ExampleScope_DumpMixed() {
+	m := NewMock("bs10")
+	m.Signal = func(i int) byte { return 100 }
+
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.Horizontal(1, 40)
+	bs.TraceMixed(0, 128, 0)
+
+	t, err := bs.DumpMixed(4)
+	fmt.Println(t.Rate, t.Analog, t.Logic.Raw, err)
+	// Output: 500000 [100 100 100 100] [0 1 2 3] <nil>
+}
+
+func ExampleScope_DumpMacro() {
+	m := NewMock("bs10")
+	m.Signal = func(i int) byte { return byte(i) }
+
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.TraceMacro(0, 256, 0)
+	b, err := bs.DumpMacro(6)
+	fmt.Println(b, err)
+	// Output: [0 1 2 3 4 5] <nil>
+}
+
+func ExampleScope_Windows() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.TriggerTiming(0, 0, 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	n := 0
+	windows := bs.Windows(ctx, 64)
+	for r := range windows {
+		if r.Err != nil {
+			log.Fatal(r.Err)
+		}
+		n += len(r.Data)
+		if n == 256 {
+			break
+		}
+	}
+
+	// The trigger timing is restored when Windows ends
+	cancel()
+	for range windows {
+	}
+	fmt.Println(n, m.Reg(byte(RegTimeout)))
+	// Output: 256 100
+}
+
+func ExampleScope_TraceRepeat() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	n := 0
+	err := bs.TraceRepeat(TraceConfig{Post: 256}, 10, func(data []byte) bool {
+		n++
+		return len(data) == 256
+	})
+	fmt.Println(n, err)
+	// Output: 10 <nil>
+}
+
+func ExampleScope_Average() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	mean, std, err := bs.Average(TraceConfig{Post: 64}, 8)
+	fmt.Println(len(mean), mean[16], std[16], err)
+	// Output: 64 228 0 <nil>
+}
+
+func ExampleScope_Envelope() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	// One bucket per period of the mock sine wave
+	min, max, err := bs.Envelope(TraceConfig{Post: 256}, 4, 64)
+	fmt.Println(min, max, err)
+	// Output: [28 28 28 28] [228 228 228 228] <nil>
+}
+
+func ExampleScope_SetTimeWindow() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	fmt.Println(bs.SetSampleRate(1e6))
+
+	cfg, rate, err := bs.SetTimeWindow(time.Millisecond, 9*time.Millisecond)
+	fmt.Println(cfg.Pre, cfg.Post, rate, err)
+	// Output:
+	// 1e+06 <nil>
+	// 1212 10909 1.2121212121212122e+06 <nil>
+}
+
+func ExampleScope_DumpAll() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	bs.Trace(0, 256, 0)
+
+	all, _ := bs.DumpAll()
+	part, err := bs.DumpRange(5000, 100)
+	fmt.Println(len(all), bytes.Equal(part, all[5000:5100]), err)
+	// Output: 12288 true <nil>
+}
+
+func ExampleScope_Acquire() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	bs.Vertical("5.2v")
+	bs.SetSampleRate(1e6)
+
+	t, err := bs.Acquire(TraceConfig{Pre: 100, Post: 900})
+	fmt.Println(len(t.Samples), t.SampleRate, t.Range, t.TriggerIndex, err)
+	// Output: 1000 1e+06 5.2 100 <nil>
+}
+
+func ExampleTrace_Volts() {
+	t := &Trace{Samples: []byte{0, 51, 255}, Range: 5}
+	fmt.Println(t.Volts())
+	// Output: [-2.5 -1.5 2.5]
+}
+
+func ExampleScope_Arm() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	bs.Arm(TraceConfig{Post: 256})
+
+	// The BitScope only takes the commands of the acquisition until Fetch
+	fmt.Println(bs.Vertical("5.2v"))
+
+	for {
+		s, err := bs.Status()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if s == Done {
+			break
+		}
+		// Do something else
+	}
+
+	t, err := bs.Fetch()
+	fmt.Println(len(t.Samples), err)
+	fmt.Println(bs.Vertical("5.2v"))
+	// Output:
+	// Acquisition armed
+	// 256 <nil>
+	// <nil>
+}
+
+func ExampleScope_InputSource() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.InputSource('a', POD)
+	bs.Trace(0, 256, 0)
+	fmt.Println(m.Reg(byte(RegAnalogEnable)))
+	// Output: 4
+}
+
+func ExampleScope_Attenuation() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	bs.Vertical("5.2v")
+	bs.Attenuation('a', 10)
+
+	fmt.Println(bs.Volts([]byte{0, 255}))
+	// Output: [-26 26]
+}
+
+func ExampleScope_DumpWith() {
+	m := NewMock("bs10")
+	m.Signal = func(i int) byte { return byte(i) }
+
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.Trace(0, 1024, 0)
+
+	d, _ := bs.DumpWith(4, Decimate(10))
+	f, err := bs.DumpWith(4, Filtered(10))
+	fmt.Println(d, f, err)
+	// Output: [0 10 20 30] [4 14 24 34] <nil>
+}
+
+func ExampleStrided() {
+	m := NewMock("bs10")
+	m.Signal = func(i int) byte { return byte(i) }
+
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.Trace(0, 1024, 0)
+
+	odd, _ := bs.DumpWith(4, Strided(1, 1, 1).From(1))
+	twice, err := bs.DumpWith(6, Strided(1, 2, 0))
+	fmt.Println(odd, twice, err)
+	// Output: [1 3 5 7] [0 0 1 1 2 2] <nil>
+}
+
+func ExampleScope_Segments() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	seg, err := bs.Segments(TraceConfig{Pre: 16, Post: 48}, 5)
+	fmt.Println(len(seg), len(seg[4].Data), seg[4].TriggerIndex, err)
+	// Output: 5 64 16 <nil>
+}
+
+func ExampleScope_SetPrelude() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	bs.SetPrelude(0x80)
+	bs.Trace(0, 256, 0)
+
+	// Samples after the trace were not written
+	b, err := bs.DumpRange(256, 4)
+	fmt.Println(b, err)
+	// Output: [128 128 128 128] <nil>
+}
+
+func ExampleScope_MeasureFrequency() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	// The mock sine wave has a period of 64 samples: 15625 Hz
+	bs.SetSampleRate(1e6)
+	f, err := bs.MeasureFrequency('a')
+	fmt.Printf("%.0f %v\n", f, err)
+	// Output: 15610 <nil>
+}
+
+func ExampleScope_CounterRead() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	bs.Trace(100, 900, 0)
+	n, err := bs.CounterRead()
+	fmt.Println(n, err)
+	// Output: 1000 <nil>
+}
+
+func ExampleAcqConfig_Validate() {
+	c := AcqConfig{
+		Model:      "bs05",
+		Channels:   []byte{'a', 'b'},
+		SampleRate: 1e6,
+		Pre:        1000,
+		Post:       9000,
+		Range:      "3.5v",
+		Trigger:    TriggerSpec{Channel: 'a', Level: 0.5},
+	}
+	fmt.Println(c.Validate())
+
+	c.Channels = []byte{'a'}
+	fmt.Println(c.Validate())
+
+	for _, r := range []string{"", "bogus", "20v", "-1v"} {
+		c.Range = r
+		fmt.Println(c.Validate())
+	}
+	// Output:
+	// Record of 10000 samples longer than the buffer (6144 samples per channel)
+	// <nil>
+	// Range "": Invalid vertical range
+	// Range "bogus": Invalid vertical range
+	// Range "20v": Unsupported vertical range
+	// Range "-1v": Invalid vertical range
+}
+
+func ExampleScope_TriggerLevelVolts() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.Vertical("5.2v")
+	err := bs.TriggerLevelVolts('a', 0)
+	fmt.Printf("%02x%02x %v\n", m.Reg(byte(RegTriggerLevel)+1), m.Reg(byte(RegTriggerLevel)), err)
+	// Output: 7f80 <nil>
+}
+
+func ExampleScope_TriggerWindow() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.Vertical("5.2v")
+	err := bs.TriggerWindow('b', -1, 1, false)
+	fmt.Printf("%02x%02x %02x%02x %02x %v\n",
+		m.Reg(byte(RegTriggerLevel)+1), m.Reg(byte(RegTriggerLevel)),
+		m.Reg(byte(RegTriggerUpper)+1), m.Reg(byte(RegTriggerUpper)),
+		m.Reg(byte(RegSpockOption)), err)
+
+	// The inversion is kept
+	bs.TriggerInvert(true)
+	err = bs.TriggerWindow('a', -1, 1, true)
+	fmt.Printf("%02x %v\n", m.Reg(byte(RegSpockOption)), err)
+	// Output:
+	// 4e76 b08a 37 <nil>
+	// 63 <nil>
+}
+
+func ExampleScope_SetTriggerSource() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	err := bs.SetTriggerSource(TrigCHB)
+	fmt.Printf("%02x %02x %02x %v\n", m.Reg(byte(RegSpockOption)),
+		m.Reg(byte(RegTriggerLogic)), m.Reg(byte(RegTriggerMask)), err)
+
+	bs.TriggerLogic(0x05, 0xf0)
+	err = bs.SetTriggerSource(TrigLogic)
+	fmt.Printf("%02x %02x %02x %v\n", m.Reg(byte(RegSpockOption)),
+		m.Reg(byte(RegTriggerLogic)), m.Reg(byte(RegTriggerMask)), err)
+
+	// CHA is sampled from the POD header only while it is the external
+	// trigger
+	bs.SetTriggerSource(TrigExternal)
+	bs.Trace(0, 16, 0)
+	fmt.Printf("%02x %02x\n", m.Reg(byte(RegSpockOption)), m.Reg(byte(RegAnalogEnable)))
+	bs.SetTriggerSource(TrigCHA)
+	bs.Trace(0, 16, 0)
+	fmt.Printf("%02x %02x\n", m.Reg(byte(RegSpockOption)), m.Reg(byte(RegAnalogEnable)))
+	// Output:
+	// 25 80 7f <nil>
+	// 20 05 f0 <nil>
+	// 21 04
+	// 21 01
+}
+
+func ExampleScope_TriggerPattern() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	err := bs.TriggerPattern("1X0X XXF0")
+	fmt.Printf("%02x %02x %02x %v\n", m.Reg(byte(RegTriggerLogic)),
+		m.Reg(byte(RegTriggerMask)), m.Reg(byte(RegSpockOption)), err)
+	// Output: 80 5c 20 <nil>
+}
+
+func ExampleScope_TriggerPattern_edge() {
+	// Input 0 rises at sample 10, input 1 at sample 20
+	m := NewMock("bs10")
+	m.Logic = func(i int) byte {
+		var b byte
+		if i%64 >= 10 {
+			b |= 1
+		}
+		if i%64 >= 20 {
+			b |= 2
+		}
+		return b
+	}
+	bs, _ := New(m)
+	defer bs.Close()
+
+	// Input 0 was already high when input 1 completed the match
+	bs.TriggerPattern("XXXX XX1R")
+	bs.LogicTrace(4, 8, 0)
+	l, err := bs.LogicDump(12)
+	fmt.Printf("%02x %v\n", l.Raw[2:6], err)
+	// Output: 01010303 <nil>
+}
+
+func ExampleScope_TriggerHysteresis() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.Vertical("5.2v")
+	bs.TriggerLevelVolts('a', 0)
+	err := bs.TriggerHysteresis(HysteresisAuto)
+	fmt.Printf("%02x%02x %02x%02x %02x %v\n",
+		m.Reg(byte(RegTriggerLevel)+1), m.Reg(byte(RegTriggerLevel)),
+		m.Reg(byte(RegTriggerUpper)+1), m.Reg(byte(RegTriggerUpper)),
+		m.Reg(byte(RegSpockOption)), err)
+	// Output: 7e80 8080 23 <nil>
+}
+
+func ExampleScope_TriggerTimingDuration() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	bs.SetSampleRate(1e6)
+	hoff, hon, timeout, err := bs.TriggerTimingDuration(10*time.Microsecond, 2500*time.Nanosecond, time.Millisecond)
+	fmt.Println(hoff, hon, timeout, err)
+	// Output: 10µs 3µs 998.4µs <nil>
+}
+
+func ExampleScope_ForceTrigger() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	// Nothing to trigger
+	fmt.Println(bs.ForceTrigger())
+	// Output: No acquisition armed
+}
+
+func ExampleScope_TriggerInvert() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.TriggerInvert(true)
+	bs.TriggerSwap(true)
+	bs.TriggerMode(trigger.Rising, true)
+	fmt.Printf("%02x\n", m.Reg(byte(RegSpockOption)))
+
+	bs.TriggerInvert(false)
+	fmt.Printf("%02x\n", m.Reg(byte(RegSpockOption)))
+	// Output:
+	// 63
+	// 23
+}
+
+func ExampleScope_TriggerDelay() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	d, err := bs.TriggerDelay(2500 * time.Microsecond)
+	bs.Trace(0, 100, 0)
+	fmt.Printf("%v %v %02x%02x\n", d, err, m.Reg(byte(RegTraceDelay)+1), m.Reg(byte(RegTraceDelay)))
+
+	_, err = bs.TriggerDelay(-time.Second)
+	fmt.Println(err)
+	// Output:
+	// 2.5ms <nil> 09c4
+	// Trigger delay out of range
+}
+
+func ExampleScope_NotifyTrigger() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	ch := make(chan TriggerEvent, 1)
+	bs.NotifyTrigger(ch)
+
+	bs.Trace(100, 100, 0)
+	ev := <-ch
+	fmt.Println(ev.Address, !ev.Time.IsZero())
+	// Output: 304 true
+}
+
+func ExampleTriggerCause() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	t, _ := bs.Acquire(TraceConfig{Pre: 100, Post: 100})
+	fmt.Println(t.Cause)
+	// Output: trigger
+}
+
+func ExampleGenerator() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	g := bs.Gen()
+	f, _ := g.SetFrequency(440)
+	g.SetAmplitude(2)
+	err := g.Start()
+	fmt.Printf("%.2f %v %02x %v\n", f, g.Running(), m.Reg(byte(RegKitchenSinkB)), err)
+
+	g.Stop()
+	fmt.Printf("%v %02x\n", g.Running(), m.Reg(byte(RegKitchenSinkB)))
+	// Output:
+	// 440.00 true c0 <nil>
+	// false 80
+}
+
+func ExampleGenerator_Sine() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	g := bs.Gen()
+	err := g.Sine(1000, 2)
+	w := m.Wave(1000)
+	fmt.Println(w[0], w[250], w[500], w[750], err)
+
+	err = g.Square(100e3, 1)
+	w = m.Wave(400)
+	fmt.Println(w[0], w[199], w[200], w[399], err)
+	// Output:
+	// 128 255 128 0 <nil>
+	// 255 255 0 0 <nil>
+}
+
+func ExampleGenerator_Load() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	err := bs.Gen().Load([]float64{0, 0.5, 1, 0.5}, 1e6)
+	fmt.Println(m.Wave(4), err)
+	// Output: [0 128 255 128] <nil>
+}
+
+func ExampleGenerator_Sweep() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	cfg := SweepConfig{Start: 100, Stop: 10000, Steps: 3, Log: true}
+	for s := range bs.Gen().Sweep(context.Background(), cfg) {
+		fmt.Printf("%.1f %v\n", s.Freq, s.Err)
+	}
+	// Output:
+	// 100.0 <nil>
+	// 1000.0 <nil>
+	// 10000.0 <nil>
+}
+
+func ExampleScope_ClockOut() {
+	bs, _ := New(NewMock("bs10"))
+	defer bs.Close()
+
+	f, d, err := bs.ClockOut(3e6, 0.25)
+	fmt.Printf("%.0f %.3f %v\n", f, d, err)
+	// Output: 3076923 0.231 <nil>
+}
+
+func ExampleGenerator_Bode() {
+	// A sine on CHA and a cosine on CHB, with a period of 64 samples of
+	// each channel (128 sample clocks), which Bode takes at all
+	// frequencies. CHB is sampled one clock after CHA.
+	m := NewMock("bs10")
+	m.Signal = func(i int) byte { return byte(128 + 100*math.Sin(2*math.Pi*float64(i)/128)) }
+	m.SignalB = func(i int) byte { return byte(128 + 100*math.Cos(2*math.Pi*float64(i)/128)) }
+
+	bs, _ := New(m)
+	defer bs.Close()
+	bs.Horizontal(1, 40)
+
+	cfg := SweepConfig{Start: 1000, Stop: 2000, Steps: 2}
+	res, err := bs.Gen().Bode(context.Background(), cfg)
+	for _, p := range res {
+		fmt.Printf("%.0f %.2f %.1f\n", p.Freq, p.GainDB, p.Phase)
+	}
+	fmt.Println(err, bs.SampleRate())
+	// Output:
+	// 1000 -0.03 90.0
+	// 2000 -0.01 90.0
+	// <nil> 1e+06
+}
+
+func ExampleScope_StatusLeds() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.StatusLeds(true)
+	bs.Trace(100, 100, 0)
+	fmt.Println(m.Reg(byte(RegLedRed)), m.Reg(byte(RegLedGreen)), m.Reg(byte(RegLedYellow)))
+	// Output: 0 255 0
+}
+
+func ExampleScope_StatusLeds_arm() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.StatusLeds(true)
+	bs.Arm(TraceConfig{Post: 100})
+	fmt.Println(m.Reg(byte(RegLedRed)), m.Reg(byte(RegLedGreen)), m.Reg(byte(RegLedYellow)))
+	bs.Fetch()
+	fmt.Println(m.Reg(byte(RegLedRed)), m.Reg(byte(RegLedGreen)), m.Reg(byte(RegLedYellow)))
+	// Output:
+	// 0 0 255
+	// 0 255 0
+}
+
+func ExampleScope_LogicWrite() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	err := bs.LogicWrite(0x0f, 0x35)
+	fmt.Printf("%02x %02x %v\n", m.Reg(byte(RegLogicControl)), m.Reg(byte(RegLogicOutput)), err)
+	// Output: 0f 05 <nil>
+}
+
+func ExampleTrace_Resample() {
+	// A sine of 20 samples per period, with the trigger at sample 5
+	t := &Trace{SampleRate: 1e6, TriggerIndex: 5, Values: make([]float64, 200)}
+	for i := range t.Values {
+		t.Values[i] = math.Sin(2 * math.Pi * float64(i-5) / 20)
+	}
+
+	r, err := t.Resample(4e6)
+	fmt.Println(len(r.Values), r.TriggerIndex, err)
+	fmt.Printf("%.3f %.3f %.3f\n", r.Values[r.TriggerIndex+41], t.LinearAt(10.25e-6), t.SincAt(10.25e-6))
+	// Output:
+	// 797 20 <nil>
+	// -0.079 -0.077 -0.079
+}
+
+func ExampleXY() {
+	m := NewMock("bs10")
+	m.Signal = func(i int) byte { return byte(i) }
+	m.SignalB = func(i int) byte { return 255 - byte(i) }
+
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.Vertical("2v")
+	bs.Horizontal(1, 40)
+	a, b, err := bs.AcquireDual(context.Background(), TraceConfig{Post: 256})
+	fmt.Printf("%v %c %v\n", a.SampleRate, b.Channel, err)
+
+	pts, err := XY(a, b)
+	fmt.Printf("%d %.2f %v\n", len(pts), pts[0], err)
+
+	p := NewPersistence(4, 4, -1, 1, -1, 1)
+	p.Add(pts)
+	fmt.Println(p.At(0, 3), p.At(3, 0), p.At(0, 0), p.Counts)
+	// Output:
+	// 500000 b <nil>
+	// 256 {-1.75 1.74} <nil>
+	// 36 36 0 [0 0 0 36 0 0 36 0 0 36 0 0 36 0 0 0]
+}
+
+func ExampleScope_AutoSetup() {
+	// A sine of 64 samples per period and 0.86 Vpp at the 11 V range
+	m := NewMock("bs10")
+	m.Signal = func(i int) byte { return byte(128 + 10*math.Sin(2*math.Pi*float64(i)/64)) }
+
+	bs, _ := New(m)
+	defer bs.Close()
+
+	cfg, err := bs.AutoSetup('a')
+	fmt.Printf("%s %.0f %d %d %.3f %v\n", cfg.Range, cfg.SampleRate, cfg.Pre, cfg.Post, cfg.Trigger.Level, err)
+	fmt.Println(cfg.Validate())
+
+	// The default CHB of the mock is a cosine of 8.6 Vpp at the 11 V range
+	cfg, err = bs.AutoSetup('b')
+	fmt.Printf("%s %.0f %c %v\n", cfg.Range, cfg.SampleRate, cfg.Trigger.Channel, err)
+	// Output:
+	// 1.1v 3076923 512 512 0.002 <nil>
+	// <nil>
+	// 11v 10000000 b <nil>
+}
+
+func ExampleScope_Trend() {
+	// The mock signal rises by one code per trace
+	n := 0
+	m := NewMock("bs10")
+	m.Signal = func(i int) byte {
+		if i%bufferSize == 0 {
+			n++
+		}
+		return byte(100 + n)
+	}
+
+	bs, _ := New(m)
+	defer bs.Close()
+	bs.Vertical("11v")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := TrendConfig{Trace: TraceConfig{Post: 256}, Interval: time.Millisecond}
+	mean := func(t *Trace) (float64, error) {
+		s := 0.0
+		for _, v := range t.Volts() {
+			s += v
+		}
+		return s / float64(len(t.Samples)), nil
+	}
+
+	points := bs.Trend(ctx, cfg, mean)
+	for i := 0; i < 3; i++ {
+		p := <-points
+		fmt.Printf("%.3f %d %.3f %.3f %.3f %v\n", p.Value, p.N, p.Min, p.Max, p.Mean, p.Err)
+	}
+	// Output:
+	// -1.143 1 -1.143 -1.143 -1.143 <nil>
+	// -1.100 2 -1.143 -1.100 -1.122 <nil>
+	// -1.057 3 -1.143 -1.057 -1.100 <nil>
+}
+
+func ExampleTrace_TimeCursors() {
+	// A ramp of 1 V per microsecond from -2 V, with the trigger at 0 V
+	t := &Trace{SampleRate: 1e6, TriggerIndex: 2, Values: []float64{-2, -1, 0, 1, 2, 3}}
+
+	c, err := t.TimeCursors(-1.5e-6, 2e-6)
+	fmt.Printf("%.1f %.1f %.1fus %.0fkHz %.1f %v\n", c.V1, c.V2, c.DeltaT*1e6, c.Frequency/1e3, c.DeltaV, err)
+
+	c, err = t.VoltageCursors(0.5, 2.5)
+	fmt.Printf("%.1fus %.1fus %.1fus %v\n", c.T1*1e6, c.T2*1e6, c.DeltaT*1e6, err)
+
+	_, err = t.TimeCursors(0, 1)
+	fmt.Println(err)
+	// Output:
+	// -1.5 2.0 3.5us 286kHz 3.5 <nil>
+	// 0.5us 2.5us 2.0us <nil>
+	// Cursor outside of the trace
+}
+
+func ExampleNew_timeout() {
+	// A tty returns io.EOF when a read times out without data; here the
+	// VM takes a few polls to answer each command
+	m := NewMock("bs10")
+	m.EOFOnTimeout, m.Stall = true, 3
+
+	bs, err := New(&eofTimeout{Transport: m})
+	fmt.Println(bs.Model, err)
+	defer bs.Close()
+
+	_, err = bs.Trace(0, 256, 0)
+	fmt.Println(err)
+	b, err := bs.Dump(4)
+	fmt.Println(len(b), err)
+
+	// Without a deadline, io.EOF is a hangup
+	bs.tty.SetDeadline(time.Time{})
+	_, err = bs.tty.Read(make([]byte, 1))
+	fmt.Println(err)
+	// Output:
+	// bs10 <nil>
+	// <nil>
+	// 4 <nil>
+	// EOF
+}
+
+func ExampleScope_Resync() {
+	// On a tty, the read that finds the input drained times out
+	m := NewMock("bs10")
+	m.EOFOnTimeout = true
+
+	bs, _ := New(&eofTimeout{Transport: m})
+	defer bs.Close()
+
+	fmt.Println(bs.Flush(), bs.Resync())
+	// Output: <nil> <nil>
+}
+
+func ExampleScope_Status() {
+	// The trace takes a few polls to complete, and the tty returns io.EOF
+	// for a poll without data
+	m := NewMock("bs10")
+	m.EOFOnTimeout = true
+
+	bs, _ := New(&eofTimeout{Transport: m})
+	defer bs.Close()
+
+	m.Stall = 3
+	bs.Arm(TraceConfig{Post: 256})
+
+	s, err := bs.Status()
+	fmt.Println(s, err)
+
+	t, err := bs.Fetch()
+	fmt.Println(len(t.Samples), err)
+	// Output:
+	// armed <nil>
+	// 256 <nil>
+}
+
+func ExampleScope_AutoTriggerLevel() {
+	m := NewMock("bs10")
+	m.Signal = func(i int) byte { return byte(50 + 20*(i/8%2)) }
+	m.SignalB = func(i int) byte { return byte(150 + 40*(i/8%2)) }
+
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.TriggerTiming(5, 5, 0)
+
+	la, err := bs.AutoTriggerLevel('a')
+	fmt.Printf("%d %v\n", la>>8, err)
+	lb, err := bs.AutoTriggerLevel('b')
+	fmt.Printf("%d %v\n", lb>>8, err)
+
+	// The thresholds are 10% of the span apart, and the timing is kept
+	fmt.Printf("%d %d %02x %d\n", m.Reg(byte(RegTriggerLevel)+1),
+		m.Reg(byte(RegTriggerUpper)+1), m.Reg(byte(RegSpockOption)),
+		m.Reg(byte(RegTriggerIntro)))
+	// Output:
+	// 60 <nil>
+	// 170 <nil>
+	// 168 172 23 5
+}
+
+func ExampleScope_SoftwareAC() {
+	m := NewMock("bs10")
+	m.Signal = func(i int) byte { return byte(150 + 50*(i%2)) }
+
+	bs, _ := New(m)
+	defer bs.Close()
+
+	bs.Vertical("5.2v")
+	bs.SoftwareAC('a', true)
+
+	t, err := bs.Acquire(TraceConfig{Post: 4})
+	fmt.Printf("%.2f %v %v\n", t.Volts(), t.SoftwareAC, err)
+	// Output: [-0.51 0.51 -0.51 0.51] true <nil>
+}
+
+func ExampleScope_AcqState() {
+	m := NewMock("bs10")
+	bs, _ := New(m)
+	defer bs.Close()
+
+	s, err := bs.AcqState()
+	fmt.Println(s, err)
+
+	m.Stall = 3
+	bs.Arm(TraceConfig{Post: 256})
+	s, err = bs.AcqState()
+	fmt.Println(s, err)
+
+	for s == Armed {
+		s, err = bs.AcqState()
+	}
+	fmt.Println(s, err)
+
+	bs.Fetch()
+	s, err = bs.AcqState()
+	fmt.Println(s, err)
+	// Output:
+	// idle <nil>
+	// armed <nil>
+	// done <nil>
+	// idle <nil>
+}
@@ -7,38 +7,60 @@
 //
 // See https://bitscope.com for more information on these PC oscilloscopes.
 //
+// A Scope can be used from several goroutines: each method runs to
+// completion before the next one sends commands to the BitScope.
+// TraceTerminate and Close do not wait, so that they can end a trace that
+// is blocked in another goroutine.
+//
 package bitscope
 
 import (
+	"context"
 	"errors"
+	"math"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
+
+	"bitscope/trigger"
 )
 
 // Reset instructs the BitScope to do a soft reset
-func (bs *Scope) Reset() {
-	bs.call([]byte("!"))
+func (bs *Scope) Reset() error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	_, err := bs.call([]byte("!"))
+	return err
 }
 
 // Stop terminates a command sequence
-func (bs *Scope) Stop() {
-	bs.call([]byte("."))
+func (bs *Scope) Stop() error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	_, err := bs.call([]byte("."))
+	return err
 }
 
 // Led controls the intensity of the 3 LEDs of the BS10, one at a time.
-func (bs *Scope) Led(n, i uint) {
+func (bs *Scope) Led(n, i uint) error {
 
-	b := []byte("fa@00s")
-	hex1(i, b, 3)
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	r := RegLedRed
 
 	switch n {
 	case 'g': // Green
-		b[1] = 'b'
+		r = RegLedGreen
 	case 'y': // Yellow
-		b[1] = 'c'
+		r = RegLedYellow
 	}
 
-	bs.call(b)
+	_, err := bs.set("led"+string(hexByte(uint(r))), reg(r, i))
+	return err
 }
 
 /* -------------------------------------------------------------------------
@@ -46,9 +68,47 @@ func (bs *Scope) Led(n, i uint) {
    -------------------------------------------------------------------------*/
 
 // TraceTerminate is used to 'manually' end the data acquisition, instead
-// of using a trigger event.
-func (bs *Scope) TraceTerminate() {
-	bs.call([]byte("K"))
+// of using a trigger event. It can be called while Trace is waiting in
+// another goroutine.
+func (bs *Scope) TraceTerminate() error {
+
+	if atomic.LoadInt32(&bs.tracing) != 0 {
+		// The response is read by the waiting Trace
+		atomic.StoreInt32(&bs.stopped, stopTerminated)
+		_, err := bs.tty.Write([]byte("K"))
+		return err
+	}
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	// An acquisition started with Arm that is no longer tracing is
+	// complete
+	if bs.armed != nil {
+		return nil
+	}
+	return bs.traceTerminate()
+}
+
+func (bs *Scope) traceTerminate() error {
+	_, err := bs.call([]byte("K"))
+	return err
+}
+
+// ForceTrigger makes a trace that is waiting for its trigger event proceed
+// as if it had happened: unlike TraceTerminate, the post-trigger samples are
+// captured. It is meant to be called while Trace is waiting in another
+// goroutine, or after Arm, and returns ErrNotArmed otherwise.
+func (bs *Scope) ForceTrigger() error {
+
+	if atomic.LoadInt32(&bs.tracing) == 0 {
+		return ErrNotArmed
+	}
+
+	// The response is read by the waiting Trace or Fetch
+	atomic.StoreInt32(&bs.stopped, stopForced)
+	_, err := bs.tty.Write([]byte("T"))
+	return err
 }
 
 // Trace starts the data acquisition and waits until it has completed.
@@ -56,40 +116,88 @@ func (bs *Scope) TraceTerminate() {
 // of samples, and the delay is specified in us. The delay is a time window
 // after the trigger in which no samples are recorded.
 func (bs *Scope) Trace(pre, post, delay uint) ([]byte, error) {
+	return bs.TraceContext(context.Background(), pre, post, delay)
+}
+
+// TraceContext is like Trace, but gives up waiting for the acquisition to
+// complete when ctx is done. In that case the trace is terminated and the
+// error of ctx is returned.
+func (bs *Scope) TraceContext(ctx context.Context, pre, post, delay uint) ([]byte, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
 
-	bs.call([]byte("[7b]@[80]s")) // KitchenSinkA (enable hardware comparators)
-	bs.call([]byte("[7c]@[80]s")) // KitchenSinkB (enable analog filter)
-	bs.call([]byte("[37]@[01]s")) // AnalogEnable (enable CHA input circuits)
-	bs.call([]byte("[31]@[00]s")) // buffer mode
-	bs.call([]byte("[21]@[00]s")) // trace mode
-
-	// delay, pre, post
-	a := []byte("22@00z00z00z00s")
-	b := []byte("26@00z00s")
-	c := []byte("2a@00z00s")
-	hex4(delay, a, 3)
-	hex2(pre, b, 3)
-	hex2(post, c, 3)
-	bs.call(a)
-	bs.call(b)
-	bs.call(c)
-
-	// Logic trigger
-	bs.call([]byte("[06]@[7f]s"))           // TriggerMask (set the trigger logic mask)
-	bs.call([]byte("[05]@[80]s"))           // TriggerLogic (program the trigger logic)
-	bs.call([]byte("[44]@[00]s[45]@[00]s")) // TriggerValue (set digital trigger level, optional)
-	bs.call([]byte("[68]@[f5]s[69]@[68]s")) // TriggerLevel (set analog trigger level)
-	bs.call([]byte("[07]@[21]s"))           // SpockOption (choose edge triggered comparator mode)
-	bs.call([]byte("[3a]@[00]s[3b]@[00]s")) // Prelude (set the buffer default value; “zero”)
-
-	// trace start address
-	bs.call([]byte("[08]@[00]s[09]@[00]s[0a]@[00]s"))
-
-	bs.call([]byte(">"))
-	bs.call([]byte("U"))
-
-	b = []byte("D")
-	return bs.callCr(b, 5, 256)
+	return bs.trace(ctx, pre, post, delay)
+}
+
+func (bs *Scope) trace(ctx context.Context, pre, post, delay uint) ([]byte, error) {
+	return bs.traceCapture(ctx, captureAnalog, pre, post, delay)
+}
+
+func (bs *Scope) traceCapture(ctx context.Context, c capture, pre, post, delay uint) ([]byte, error) {
+	if err := bs.traceSetup(c, pre, post, delay); err != nil {
+		return nil, err
+	}
+	return bs.traceStart(ctx)
+}
+
+// traceSetup programs the registers needed for a trace of the given kind.
+// A delay of 0 means the one set with TriggerDelay.
+func (bs *Scope) traceSetup(c capture, pre, post, delay uint) error {
+
+	if delay == 0 {
+		delay = bs.delay
+	}
+
+	cmds := [][]byte{
+		reg(RegKitchenSinkA, 0x80),              // enable hardware comparators
+		reg(RegKitchenSinkB, bs.kitchenSinkB()), // analog filter, generator
+		reg(RegAnalogEnable, bs.analogEnable(c)),
+		reg(RegDigitalEnable, c.digital),
+		reg(RegBufferMode, c.bufferMode),
+		reg(RegTraceMode, c.traceMode),
+
+		reg(RegTraceDelay, delay),
+		reg(RegTraceIntro, pre),
+		reg(RegTraceOutro, post),
+
+		reg(RegTriggerMask, bs.trigMask),      // set the trigger logic mask
+		reg(RegTriggerLogic, bs.trigLogic),    // program the trigger logic
+		reg(RegTriggerValue, 0),               // set digital trigger level, optional
+		bs.triggerLevels(),                    // set analog trigger level
+		reg(RegSpockOption, bs.spockOption()), // trigger mode (see TriggerMode)
+		reg(RegPrelude, bs.prelude),           // set the buffer default value
+		reg(RegSampleAddress, 0),              // trace start address
+
+		[]byte(">"),
+		[]byte("U"),
+	}
+
+	return bs.pipeline(context.Background(), cmds...)
+}
+
+// traceStart starts a trace that has been set up and waits until it has
+// completed.
+func (bs *Scope) traceStart(ctx context.Context) ([]byte, error) {
+
+	bs.showStatus(ledsArmed)
+
+	atomic.StoreInt32(&bs.stopped, 0)
+	atomic.StoreInt32(&bs.tracing, 1)
+
+	b := []byte("D")
+	r, err := bs.callCr(ctx, b, 5)
+
+	atomic.StoreInt32(&bs.tracing, 0)
+
+	if err != nil && ctx.Err() != nil {
+		bs.traceTerminate()
+	}
+	if err == nil {
+		bs.notifyTrigger(r, time.Now())
+	}
+	bs.showTraceStatus(r, err)
+	return r, err
 }
 
 /* -------------------------------------------------------------------------
@@ -97,46 +205,149 @@ func (bs *Scope) Trace(pre, post, delay uint) ([]byte, error) {
    -------------------------------------------------------------------------*/
 
 // Dump reads the data buffer from the BitScope into a byte array. This buffer
-// contains the data acquired during the trace phase.
+// contains the data acquired during the trace phase. It is transferred in the
+// format selected with SetDumpFormat.
 func (bs *Scope) Dump(size uint) ([]byte, error) {
+	return bs.DumpContext(context.Background(), size)
+}
+
+// DumpContext is like Dump, but returns the error of ctx if it is done
+// before the data is received.
+func (bs *Scope) DumpContext(ctx context.Context, size uint) ([]byte, error) {
 
-	b := []byte("[31]@[00]s" + // BufferMode
-		"[08]@[cc]s[09]@[00]s[0a]@[00]s" + // Start address
-		"[1e]@[00]s" + // DumpMode (raw)
-		"[30]@[00]s") // DumpChan
-	bs.call(b)
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
 
-	// Set the dump size (number of data bytes to return)
-	b = []byte("1c@00z00s")
-	hex2(size, b, 3)
-	bs.call(b)
+	return bs.dump(ctx, size)
+}
+
+func (bs *Scope) dump(ctx context.Context, size uint) ([]byte, error) {
+	return bs.dumpAt(ctx, dumpBase, size)
+}
+
+// DumpRange reads length bytes of the data buffer, from the given offset
+// after the first sample of the trace on. The range must lie within the
+// buffer (12 KB); it is read in chunks of a few KB.
+func (bs *Scope) DumpRange(start, length uint) ([]byte, error) {
+
+	if start+length > bufferSize {
+		return nil, errors.New("Dump range beyond the end of the buffer")
+	}
 
-	b = []byte("[16]@[01]s[17]@[00]s" + // DumpRepeat
-		"[18]@[01]s[19]@[00]s" + // DumpSend
-		"[1a]@[ff]s[1b]@[ff]s" + // DumpSkip
-		">")
-	bs.call(b)
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
 
-	return bs.callWait([]byte("A"), 100, size+256)
+	return bs.dumpAt(context.Background(), dumpBase+start, length)
+}
+
+// DumpAll reads the whole data buffer.
+func (bs *Scope) DumpAll() ([]byte, error) {
+	return bs.DumpRange(0, bufferSize)
+}
+
+// dumpBase is the buffer address of the first sample of a trace.
+const dumpBase = 0xcc
+
+// dumpAt dumps size bytes from the given buffer address on.
+func (bs *Scope) dumpAt(ctx context.Context, start, size uint) ([]byte, error) {
+	return bs.dumpWith(ctx, start, size, Raw)
+}
+
+// dumpWith dumps size bytes, reduced as selected by opt, from the given
+// buffer address on.
+func (bs *Scope) dumpWith(ctx context.Context, start, size uint, opt DumpOption) ([]byte, error) {
+
+	// The dump is read in chunks, each of them from its own start address
+	const chunk = 4096
+
+	data := make([]byte, 0, size)
+
+	for len(data) < int(size) {
+
+		n := size - uint(len(data))
+		if n > chunk && opt.group() <= chunk {
+			// Chunks start at a group
+			n = chunk - chunk%opt.group()
+		}
+
+		if err := bs.dumpSetup(start+opt.address(uint(len(data))), n, opt); err != nil {
+			return data, err
+		}
+
+		b, err := bs.dumpChunk(ctx, n)
+		data = append(data, b...)
+		if err != nil {
+			return data, err
+		}
+		if len(b) != int(n) {
+			return data, ErrShortDump
+		}
+		if err := bs.verifyDump(ctx, start, data, opt); err != nil {
+			return data, err
+		}
+	}
+
+	return data, nil
+}
+
+// ErrShortDump is returned when a dump returns less bytes than requested.
+var ErrShortDump = errors.New("Dump returned less data than requested")
+
+// dumpSetup programs the registers for a dump of size bytes from the given
+// buffer address.
+func (bs *Scope) dumpSetup(addr, size uint, opt DumpOption) error {
+
+	return bs.pipeline(context.Background(),
+		reg(RegBufferMode, 0),
+		reg(RegSampleAddress, addr), // start address
+		reg(RegDumpMode, opt.mode),
+		reg(RegDumpChan, 0),
+		reg(RegDumpCount, size), // number of data bytes to return
+		reg(RegDumpRepeat, opt.repeat),
+		reg(RegDumpSend, opt.send),
+		reg(RegDumpSkip, opt.skip),
+		[]byte(">"))
 }
 
 /* -------------------------------------------------------------------------
    Horizontal
    -------------------------------------------------------------------------*/
 
-// Horizontal sets the time base/scale of the trace.
+// Horizontal sets the time base/scale of the trace. A sample is taken every
+// pre*div ticks of the 40 MHz master clock.
 func (bs *Scope) Horizontal(pre, div uint) error {
 
-	// Prescaler, divisor
-	b := []byte("14@00z00s" + "2e@00z00s")
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.horizontal(pre, div)
+}
 
-	hex2(pre, b, 3)
-	hex2(div, b, 12)
+func (bs *Scope) horizontal(pre, div uint) error {
 
-	_, err := bs.call(b)
+	// Prescaler, divisor
+	b := append(reg(RegClockScale, pre), reg(RegClockTicks, div)...)
+
+	_, err := bs.set("horizontal", b)
+	if err == nil {
+		bs.clockScale, bs.clockTicks = pre, div
+	}
 	return err
 }
 
+// masterClock is the frequency of the clock from which the sample clock
+// is derived, in Hz.
+const masterClock = 40e6
+
+// sampleRate returns the sample rate set with Horizontal, in samples per
+// second, or 0 if it has not been set.
+func (bs *Scope) sampleRate() float64 {
+	if bs.clockScale == 0 || bs.clockTicks == 0 {
+		return 0
+	}
+	return masterClock / float64(bs.clockScale*bs.clockTicks)
+}
+
 /* -------------------------------------------------------------------------
    Vertical
    -------------------------------------------------------------------------*/
@@ -144,7 +355,33 @@ func (bs *Scope) Horizontal(pre, div uint) error {
 // Vertical sets the voltage range of the trace.
 func (bs *Scope) Vertical(rng string) error {
 
-	var a string
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.setVertical(rng)
+}
+
+func (bs *Scope) setVertical(rng string) error {
+
+	lo, hi, r, err := vertical(bs.Model, rng)
+	if err != nil {
+		return err
+	}
+
+	// ADC range calibration
+	b := append(reg(RegConverterLo, lo), reg(RegConverterHi, hi)...)
+
+	_, err = bs.set("vertical", b)
+	if err == nil {
+		bs.vrange = r
+	}
+	return err
+}
+
+// vertical returns the converter calibration values of a range of a model,
+// and the range in volts. The range is given in volts, or in millivolts
+// with an "mv" suffix, and rounded up to the next one of the model.
+func vertical(model, rng string) (lo, hi uint, r float64, err error) {
 
 	mv := false
 
@@ -160,50 +397,51 @@ func (bs *Scope) Vertical(rng string) error {
 		mv = true
 	}
 
-	v, _ := strconv.ParseFloat(rng, 32)
+	v, err := strconv.ParseFloat(strings.TrimSpace(rng), 64)
+	if err != nil || v <= 0 {
+		return 0, 0, 0, errors.New("Invalid vertical range")
+	}
 	if mv {
 		v = v / 1000.0
 	}
 
-	switch bs.Model {
+	switch model {
 
 	case "bs10":
 		switch {
 		case v <= 0.52:
-			a = "64@54z65s" + "66@96x6cs"
+			lo, hi, r = 0x6554, 0x6c96, 0.52
 		case v <= 1.1:
-			a = "64@47z61s" + "66@a2z70s"
+			lo, hi, r = 0x6147, 0x70a2, 1.1
 		case v <= 3.5:
-			a = "64@86z50s" + "66@64z81s"
+			lo, hi, r = 0x5086, 0x8164, 3.5
 		case v <= 5.2:
-			a = "64@a7z44s" + "66@42z8ds"
+			lo, hi, r = 0x44a7, 0x8d42, 5.2
 		case v <= 11:
-			a = "64@28z1cs" + "66@c1zb5s"
+			lo, hi, r = 0x1c28, 0xb5c1, 11
 		default:
-			return errors.New("Unsupported vertical range")
+			return 0, 0, 0, errors.New("Unsupported vertical range")
 		}
 
 	case "bs05":
 		switch {
 		case v <= 1.1:
-			a = "64@d6z65s" + "66@bcz69s"
+			lo, hi, r = 0x65d6, 0x69bc, 1.1
 		case v <= 3.5:
-			a = "64@62z52s" + "66@3fz7ds"
+			lo, hi, r = 0x5262, 0x7d3f, 3.5
 		case v <= 5.2:
-			a = "64@68z44s" + "66@ffz8as"
+			lo, hi, r = 0x4468, 0x8aff, 5.2
 		case v <= 11:
-			a = "64@6az12s" + "66@8czbas"
+			lo, hi, r = 0x126a, 0xba8c, 11
 		default:
-			return errors.New("Unsupported vertical range")
+			return 0, 0, 0, errors.New("Unsupported vertical range")
 		}
 
 	default:
-		return errors.New("Unsupported model")
+		return 0, 0, 0, errors.New("Unsupported model")
 	}
 
-	bs.call([]byte(a))
-
-	return nil
+	return lo, hi, r, nil
 }
 
 /* -------------------------------------------------------------------------
@@ -211,27 +449,130 @@ func (bs *Scope) Vertical(rng string) error {
    -------------------------------------------------------------------------*/
 
 // Trigger sets the analog trigger to the specified channel and voltage threshold.
-func (bs *Scope) Trigger(src, level uint) {
+func (bs *Scope) Trigger(src, level uint) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.trigger(src, level)
+}
+
+func (bs *Scope) trigger(src, level uint) error {
 
 	bs.trigSrc = src
+	bs.trigLevel = level
+
+	_, err := bs.set("trigger", bs.triggerLevels())
+	return err
+}
+
+// TriggerLevelVolts sets the analog trigger to channel ch ('a' or 'b') with
+// a threshold in volts at the probe tip. It uses the vertical range set
+// with Vertical and the probe attenuation set with Attenuation, so it must
+// be called again when they change.
+func (bs *Scope) TriggerLevelVolts(ch uint, volts float64) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	i, err := channel(ch)
+	if err != nil {
+		return err
+	}
+	if bs.vrange == 0 {
+		return errors.New("Vertical range not set")
+	}
 
-	b := []byte("68@00z00s") // TriggerLevel (set analog trigger level)
-	hex2(level, b, 3)
-	bs.call(b)
+	return bs.trigger(ch, voltsToLevel(volts/bs.probe(i), bs.vrange))
+}
+
+// voltsToLevel converts volts at the ADC input to a TriggerLevel value, in
+// which the ADC code is in the high byte (see CodeToVolts).
+func voltsToLevel(v, rng float64) uint {
+	l := math.Round((v/rng + 0.5) * 255 * 256)
+	return uint(math.Max(0, math.Min(0xffff, l)))
+}
+
+// AutoTriggerLevel takes a quick untriggered capture of channel ch ('a' or
+// 'b'; CHB is captured with a dual channel trace), computes the amplitude
+// histogram of the signal and sets the analog trigger of the channel at
+// the 50% point between its low (10%) and high (90%) levels. The level is
+// returned in the units of Trigger.
+//
+// To reject noise, the trigger hysteresis (see TriggerHysteresis) is set to
+// 10% of the span between the low and high levels. The trigger timing is
+// left as it was before the call.
+func (bs *Scope) AutoTriggerLevel(ch uint) (uint, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	i, err := channel(ch)
+	if err != nil {
+		return 0, err
+	}
+
+	// Timeout after 1 tick, so that the trace completes without a trigger
+	defer bs.restoreTiming()()
+	if err := bs.triggerTiming(0, 0, 1); err != nil {
+		return 0, err
+	}
+
+	b, err := bs.probeChannel(i)
+	if err != nil {
+		return 0, err
+	}
+
+	var hist [256]int
+	for _, c := range b {
+		hist[c]++
+	}
+
+	lo := percentile(&hist, len(b)/10)
+	hi := percentile(&hist, len(b)*9/10)
+
+	level := (lo + hi) / 2 << 8
+
+	// The level is programmed with the hysteresis
+	bs.trigSrc = ch
+	bs.trigLevel = level
+
+	return level, bs.hysteresis((hi - lo) / 10 << 8)
+}
+
+// percentile returns the sample value below which n samples of the
+// histogram fall.
+func percentile(hist *[256]int, n int) uint {
+	sum := 0
+	for i, c := range hist {
+		sum += c
+		if sum > n {
+			return uint(i)
+		}
+	}
+	return 255
 }
 
 // TriggerLogic sets the trigger to logic mode with the given bit levels and
 // mask. The mask parameter identifies bits whose state is to be ignored by
 // the trigger comparator.
-func (bs *Scope) TriggerLogic(level, mask uint) {
+func (bs *Scope) TriggerLogic(level, mask uint) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
 
-	// TriggerMask, TriggerLogic, Level ???
-	b := []byte("05@00s" + "06@00s")
+	return bs.triggerLogic(level, mask)
+}
 
-	hex1(level, b, 3)
-	hex1(mask, b, 9)
+func (bs *Scope) triggerLogic(level, mask uint) error {
 
-	bs.call(b)
+	b := append(reg(RegTriggerLogic, level), reg(RegTriggerMask, mask)...)
+
+	_, err := bs.set("triggerlogic", b)
+	if err == nil {
+		bs.trigLogic, bs.trigMask = level, mask
+	}
+	return err
 }
 
 /*
@@ -245,20 +586,27 @@ func (bs *Scope) TriggerLogic(level, mask uint) {
 
 */
 
-// TriggerMode sets the mode (level or edge), edge (0->1 or 1->0), and hardware
-// comparator (active or not).
-//
-// TODO: invert, swap: ??
-func (bs *Scope) TriggerMode(mod, edge, comp bool) {
+// TriggerMode sets the trigger mode (an edge or a level), and whether the
+// hardware comparator is used instead of the sampled analog trigger. The
+// trigger source and the invert and swap options are kept (see
+// SetTriggerSource, TriggerInvert and TriggerSwap).
+func (bs *Scope) TriggerMode(m trigger.Mode, comp bool) error {
 
-	var mode uint
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
 
-	if mod {
-		mode |= 0x20
-	}
+	var mode uint
 
-	if edge {
-		mode |= 0x10
+	switch m {
+	case trigger.Rising:
+		mode = 0x20
+	case trigger.Falling:
+		mode = 0x30
+	case trigger.LevelAbove:
+	case trigger.LevelBelow:
+		mode = 0x10
+	default:
+		return errors.New("Unknown trigger mode")
 	}
 
 	if comp {
@@ -269,9 +617,47 @@ func (bs *Scope) TriggerMode(mod, edge, comp bool) {
 		mode |= 4
 	}
 
-	b := []byte("07@00s")
-	hex1(mode, b, 3)
-	bs.call(b)
+	return bs.triggerMode(mode | bs.spock&0x42)
+}
+
+// TriggerInvert inverts the polarity of the trigger condition.
+func (bs *Scope) TriggerInvert(on bool) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.triggerFlag(0x40, on)
+}
+
+// TriggerSwap makes the trigger swap the channel (and, with two thresholds,
+// the threshold) it looks at when it fires, as needed for dual channel chop
+// captures. TriggerWindow and TriggerHysteresis set it.
+func (bs *Scope) TriggerSwap(on bool) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.triggerFlag(0x02, on)
+}
+
+// triggerFlag sets or clears bits of the trigger mode.
+func (bs *Scope) triggerFlag(bits uint, on bool) error {
+	if on {
+		return bs.triggerMode(bs.spock | bits)
+	}
+	return bs.triggerMode(bs.spock &^ bits)
+}
+
+// triggerMode sets the SpockOption register, which is also programmed by
+// each trace.
+func (bs *Scope) triggerMode(mode uint) error {
+	old := bs.spock
+	bs.spock = mode
+	_, err := bs.set("triggermode", reg(RegSpockOption, bs.spockOption()))
+	if err != nil {
+		bs.spock = old
+	}
+	return err
 }
 
 // TriggerTiming sets the timing parameters associated with a trigger.
@@ -283,14 +669,36 @@ func (bs *Scope) TriggerMode(mod, edge, comp bool) {
 // Hold-off time: 0 .. 2^16; tick = 1/Fs
 // Hold-on time: 0 .. 2^16; tick = 1/Fs
 // Timeout: 0 .. 2^16; tick = 6.4 us. 0 = no timeout.
-func (bs *Scope) TriggerTiming(hoff, hon, timeout uint) {
+func (bs *Scope) TriggerTiming(hoff, hon, timeout uint) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.triggerTiming(hoff, hon, timeout)
+}
+
+func (bs *Scope) triggerTiming(hoff, hon, timeout uint) error {
 
-	// TriggerIntro, TriggerOutro, vrTimeout
-	b := []byte("32@00z00s" + "34@00z00s" + "2c@00z00s")
+	var b []byte
+	b = append(b, reg(RegTriggerIntro, hoff)...)
+	b = append(b, reg(RegTriggerOutro, hon)...)
+	b = append(b, reg(RegTimeout, timeout)...)
 
-	hex2(hoff, b, 3)
-	hex2(hon, b, 12)
-	hex2(timeout, b, 21)
+	_, err := bs.set("triggertiming", b)
+	if err == nil {
+		bs.timing = [3]uint{hoff, hon, timeout}
+	}
+	return err
+}
 
-	bs.call(b)
+// restoreTiming returns a function that restores the time base and the
+// trigger timing, for the functions that change them for their captures.
+func (bs *Scope) restoreTiming() func() {
+	pre, div, t := bs.clockScale, bs.clockTicks, bs.timing
+	return func() {
+		if pre != 0 && div != 0 {
+			bs.horizontal(pre, div)
+		}
+		bs.triggerTiming(t[0], t[1], t[2])
+	}
 }
@@ -0,0 +1,247 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// AcqState is the state of an acquisition started with Arm.
+type AcqState int
+
+const (
+	// No acquisition armed
+	Idle AcqState = iota
+	// Waiting for the trigger or filling the buffer
+	Armed
+	// Complete, the data can be fetched
+	Done
+	// Complete, ended by the trigger timeout or TraceTerminate instead of
+	// a trigger event
+	Untriggered
+)
+
+func (s AcqState) String() string {
+	switch s {
+	case Idle:
+		return "idle"
+	case Armed:
+		return "armed"
+	case Done:
+		return "done"
+	case Untriggered:
+		return "untriggered"
+	}
+	return "unknown"
+}
+
+// ErrNotArmed is returned by Fetch when no acquisition has been armed.
+var ErrNotArmed = errors.New("No acquisition armed")
+
+// ErrArmed is returned by the commands called after Arm, before Fetch.
+var ErrArmed = errors.New("Acquisition armed")
+
+// armed is an acquisition started by Arm.
+type armed struct {
+	cfg  TraceConfig
+	resp []byte
+	done bool
+	t    time.Time
+	// Status reported by the VM at the end of the trace
+	status uint64
+}
+
+// traceFrame is the response of the VM to 'D'.
+var traceFrame = frame{lines: 5}
+
+// Arm sets up and starts a trace of CHA, without waiting for it to
+// complete. The progress can be polled with Status, and the result read
+// with Fetch; TraceTerminate ends the trace without a trigger, and
+// ForceTrigger as if it had happened. Any other command returns ErrArmed
+// until Fetch has been called.
+func (bs *Scope) Arm(cfg TraceConfig) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if bs.armed != nil {
+		return ErrArmed
+	}
+
+	if err := bs.traceSetup(captureAnalog, cfg.Pre, cfg.Post, cfg.Delay); err != nil {
+		return err
+	}
+
+	if bs.logLevel >= LogCommands {
+		bs.logger.Printf("bitscope: TX %q", "D")
+	}
+
+	bs.showStatus(ledsArmed)
+
+	atomic.StoreInt32(&bs.stopped, 0)
+	atomic.StoreInt32(&bs.tracing, 1)
+
+	if _, err := bs.tty.Write([]byte("D")); err != nil {
+		atomic.StoreInt32(&bs.tracing, 0)
+		return bs.lost(err)
+	}
+
+	bs.armed = &armed{cfg: cfg}
+	return nil
+}
+
+// Status returns the state of the acquisition started with Arm, after a
+// single poll of the response of the VM. The poll waits for at most 1 ms,
+// but the read timeouts of a serial port have a resolution of 100 ms on
+// POSIX systems, so there it can block that long when the trace is not
+// complete.
+func (bs *Scope) Status() (AcqState, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if bs.armed == nil {
+		return Idle, nil
+	}
+	if bs.armed.done {
+		return Done, nil
+	}
+
+	// A single short read
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	if err := bs.pollArmed(ctx); err != nil && err != context.DeadlineExceeded {
+		return Armed, err
+	}
+	if bs.armed.done {
+		return Done, nil
+	}
+	return Armed, nil
+}
+
+// AcqState is Status with more detail: a complete acquisition is reported
+// as Untriggered if the VM reports that it was not ended by a trigger
+// event. The VM does not accept commands during a trace, so there is no
+// way to tell whether an armed acquisition is still taking its pre-trigger
+// samples, waiting for the trigger, or taking its post-trigger samples.
+func (bs *Scope) AcqState() (AcqState, error) {
+
+	s, err := bs.Status()
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	a := bs.armed
+	if err != nil || a == nil {
+		return s, err
+	}
+	if s == Done && a.status != 0 {
+		return Untriggered, nil
+	}
+	return s, nil
+}
+
+// Fetch waits until the acquisition started with Arm is complete, and
+// returns it.
+func (bs *Scope) Fetch() (*Trace, error) {
+	return bs.FetchContext(context.Background())
+}
+
+// FetchContext is Fetch with a context. If ctx is done first, its error
+// is returned and the acquisition stays armed.
+func (bs *Scope) FetchContext(ctx context.Context) (*Trace, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	a := bs.armed
+	if a == nil {
+		return nil, ErrNotArmed
+	}
+
+	for !a.done {
+		if err := bs.pollArmed(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			bs.armed = nil
+			return nil, err
+		}
+	}
+	bs.armed = nil
+	bs.showTraceStatus(a.resp, nil)
+
+	size := a.cfg.Size
+	if size == 0 {
+		size = a.cfg.Pre + a.cfg.Post
+	}
+
+	b, err := bs.dump(ctx, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return bs.newTrace(b, a.resp, a.cfg.Pre, a.t), nil
+}
+
+// checkArmed returns ErrArmed if an acquisition started with Arm has not
+// been fetched. The exchanges of a resync or a reconnection are allowed.
+func (bs *Scope) checkArmed() error {
+	if bs.armed != nil && !bs.resyncing && !bs.reconnecting {
+		return ErrArmed
+	}
+	return nil
+}
+
+// pollArmed reads the response to 'D' that is available, waiting at most
+// until ctx is done, and marks the acquisition as done when it is
+// complete.
+func (bs *Scope) pollArmed(ctx context.Context) error {
+
+	a := bs.armed
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	bs.tty.SetDeadline(pollDeadline(ctx))
+	defer bs.tty.SetDeadline(time.Time{})
+
+	// No data before the deadline is (0, nil) (see Transport)
+	r := make([]byte, 256)
+	n, err := bs.tty.Read(r)
+	if err != nil {
+		atomic.StoreInt32(&bs.tracing, 0)
+		return bs.lost(err)
+	}
+	a.resp = append(a.resp, r[:n]...)
+
+	if !traceFrame.complete([]byte("D"), a.resp) {
+		return ctx.Err()
+	}
+
+	atomic.StoreInt32(&bs.tracing, 0)
+
+	if bs.logLevel >= LogRaw {
+		bs.logger.Printf("bitscope: RX %q", a.resp)
+	}
+
+	a.done = true
+	a.t = time.Now()
+
+	if !bytes.HasPrefix(a.resp, []byte("D")) {
+		bs.resync()
+		return ErrOutOfSync
+	}
+
+	if t, ok := parseTrace(a.resp); ok {
+		a.status = t.status
+	}
+	bs.notifyTrigger(a.resp, a.t)
+	return nil
+}
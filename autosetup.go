@@ -0,0 +1,224 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"errors"
+	"math"
+)
+
+// Vertical ranges, from the smallest, as accepted by Vertical
+var ranges = []string{"0.52v", "1.1v", "3.5v", "5.2v", "11v"}
+
+const (
+	// Samples of the captures of AutoSetup, and of the configuration it
+	// returns
+	autoSamples = 1024
+	// Periods of the signal in the returned configuration
+	autoPeriods = 5
+	// Sample rate at which the search of the time base starts, if none
+	// is set, and number of captures of the search
+	autoRate  = 1e6
+	autoTries = 6
+)
+
+// AutoSetup probes the signal of channel ch ('a' or 'b') with quick
+// untriggered captures, and sets:
+//
+//   - the smallest vertical range in which the signal takes no more than
+//     80% of the scale,
+//   - a sample rate at which a record of 1024 samples shows about 5
+//     periods (the rate is kept if no periodic signal is found),
+//   - a rising edge trigger on the channel halfway between the low and
+//     high levels of the signal, with a hold-off and hold-on of 2
+//     samples.
+//
+// It returns the configuration chosen, with the trigger in the middle of
+// the record. CHB is probed with dual channel captures.
+func (bs *Scope) AutoSetup(ch uint) (AcqConfig, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	i, err := channel(ch)
+	if err != nil {
+		return AcqConfig{}, err
+	}
+	cfg := AcqConfig{
+		Model:      bs.Model,
+		Channels:   []byte{byte('a' + i)},
+		SoftwareAC: bs.softAC[i],
+		Pre:        autoSamples / 2,
+		Post:       autoSamples / 2,
+	}
+	cfg.Trigger.Channel = cfg.Channels[0]
+
+	// Timeout after 1 tick, so that the probes complete without a trigger
+	if err := bs.triggerTiming(0, 0, 1); err != nil {
+		return cfg, err
+	}
+	if bs.sampleRate() == 0 {
+		if _, err := bs.setSampleRate(autoRate); err != nil {
+			return cfg, err
+		}
+	}
+
+	// Vertical: from the largest range down, as long as the signal fits
+	var rng []string
+	for _, r := range ranges {
+		if _, _, _, err := vertical(bs.Model, r); err == nil {
+			rng = append(rng, r)
+		}
+	}
+	k := len(rng) - 1
+	if err := bs.setVertical(rng[k]); err != nil {
+		return cfg, err
+	}
+	b, err := bs.probeChannel(i)
+	if err != nil {
+		return cfg, err
+	}
+	lo, hi := codeExtremes(b)
+	vlo, vhi := CodeToVolts(lo, bs.vrange), CodeToVolts(hi, bs.vrange)
+	for k > 0 {
+		_, _, r, _ := vertical(bs.Model, rng[k-1])
+		if vhi-vlo > 0.8*r || math.Max(-vlo, vhi) > 0.45*r {
+			break
+		}
+		k--
+	}
+	for {
+		if err := bs.setVertical(rng[k]); err != nil {
+			return cfg, err
+		}
+		if b, err = bs.probeChannel(i); err != nil {
+			return cfg, err
+		}
+		// The levels were estimated from few codes, so check for clipping
+		lo, hi = codeExtremes(b)
+		if (lo > 0 && hi < 255) || k == len(rng)-1 {
+			break
+		}
+		k++
+	}
+	cfg.Range = rng[k]
+
+	// Time base: change the rate tenfold until a few periods are captured.
+	// The period in samples gives the rate for the record in either mode.
+	rate := bs.sampleRate()
+	for n := 0; n < autoTries; n++ {
+		c, p := periods(b, lo, hi)
+		r := bs.sampleRate()
+		hz := r * 10
+		if c >= 2 && p >= 8 {
+			rate = r / p * autoSamples / autoPeriods
+			break
+		}
+		if c < 2 {
+			hz = r / 10
+		}
+		hz = math.Max(masterClock/maxTicks, math.Min(masterClock/minTicks, hz))
+		if hz == r {
+			break
+		}
+		if _, err := bs.setSampleRate(hz); err != nil {
+			return cfg, err
+		}
+		if b, err = bs.probeChannel(i); err != nil {
+			return cfg, err
+		}
+		lo, hi = codeExtremes(b)
+	}
+	rate = math.Max(masterClock/maxTicks, math.Min(masterClock/minTicks, rate))
+	if _, err := bs.setSampleRate(rate); err != nil {
+		return cfg, err
+	}
+	cfg.SampleRate = bs.sampleRate()
+	if i == 1 {
+		cfg.SampleRate /= 2
+	}
+
+	// Trigger
+	mid := (uint(lo) + uint(hi)) / 2
+	if err := bs.trigger(uint(cfg.Trigger.Channel), mid<<8); err != nil {
+		return cfg, err
+	}
+	if err := bs.triggerMode(0x20 | uint(i)<<2 | bs.spock&0x42); err != nil {
+		return cfg, err
+	}
+	cfg.Trigger.Level = CodeToVolts(byte(mid), bs.vrange) * bs.probe(i)
+
+	return cfg, bs.triggerTiming(2, 2, 0)
+}
+
+// probeChannel does an untriggered capture and returns the samples of
+// channel i.
+func (bs *Scope) probeChannel(i int) ([]byte, error) {
+
+	ctx := context.Background()
+
+	c, n := captureAnalog, uint(autoSamples)
+	if i == 1 {
+		c, n = captureDual, 2*autoSamples
+	}
+	if _, err := bs.traceCapture(ctx, c, 0, n, 0); err != nil {
+		return nil, err
+	}
+	b, err := bs.dump(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("No samples captured")
+	}
+	if i == 1 {
+		_, b = deinterleave(b)
+	}
+	return b, nil
+}
+
+// codeExtremes returns the lowest and highest codes of the samples.
+func codeExtremes(b []byte) (lo, hi byte) {
+	lo, hi = 255, 0
+	for _, c := range b {
+		if c < lo {
+			lo = c
+		}
+		if c > hi {
+			hi = c
+		}
+	}
+	return lo, hi
+}
+
+// periods counts the rising crossings of the middle level between lo and
+// hi, with a hysteresis of a tenth of the span, and returns their number
+// and the mean period in samples.
+func periods(b []byte, lo, hi byte) (int, float64) {
+
+	h := (int(hi) - int(lo)) / 10
+	if h == 0 {
+		return 0, 0
+	}
+	mid := (int(lo) + int(hi)) / 2
+
+	n, first, last, armed := 0, 0, 0, false
+	for i, c := range b {
+		switch {
+		case int(c) <= mid-h:
+			armed = true
+		case int(c) >= mid+h && armed:
+			armed = false
+			if n == 0 {
+				first = i
+			}
+			last = i
+			n++
+		}
+	}
+	if n < 2 {
+		return n, 0
+	}
+	return n, float64(last-first) / float64(n-1)
+}
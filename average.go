@@ -0,0 +1,47 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"errors"
+	"math"
+)
+
+// Average captures n triggered traces (see TraceRepeat) and returns the
+// mean of each sample over them, and its standard deviation. Averaging
+// reduces the noise of a repetitive signal by the square root of n.
+func (bs *Scope) Average(cfg TraceConfig, n int) (mean, std []float64, err error) {
+
+	if n < 1 {
+		return nil, nil, errors.New("Average needs at least one trace")
+	}
+
+	var sum, sum2 []float64
+
+	err = bs.TraceRepeat(cfg, n, func(data []byte) bool {
+		if sum == nil {
+			sum = make([]float64, len(data))
+			sum2 = make([]float64, len(data))
+		}
+		for i := 0; i < len(data) && i < len(sum); i++ {
+			v := float64(data[i])
+			sum[i] += v
+			sum2[i] += v * v
+		}
+		return true
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mean = make([]float64, len(sum))
+	std = make([]float64, len(sum))
+
+	for i := range sum {
+		m := sum[i] / float64(n)
+		mean[i] = m
+		// Rounding can make the variance slightly negative
+		std[i] = math.Sqrt(math.Max(0, sum2[i]/float64(n)-m*m))
+	}
+	return mean, std, nil
+}
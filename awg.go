@@ -0,0 +1,252 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"errors"
+	"math"
+
+	"bitscope/vm"
+)
+
+// Generator is the arbitrary waveform generator of the BS10. It plays a
+// table of samples in a loop, at a rate derived from the master clock, and
+// scales the output with the level and offset registers.
+//
+// The VM executes the generator command in AwgCmd with 'Y': synthesize
+// fills the table with a sine, generate starts the output and stop ends
+// it. The output is enabled with bit 6 of KitchenSinkB. Tables computed by
+// the host are written to the waveform memory with 'E'.
+type Generator struct {
+	bs      *Scope
+	freq    float64
+	vpp     float64
+	offset  float64
+	ticks   uint
+	size    uint
+	table   []byte
+	running bool
+}
+
+// AWG commands (AwgCmd)
+const (
+	awgSynthesize = 0
+	awgGenerate   = 2
+	awgStop       = 3
+)
+
+const (
+	// Output range of the generator, in volts
+	awgRange = 3.3
+	// Default table size, in samples
+	awgSize = 1024
+	// Size of the waveform memory
+	awgMemory = 4096
+	// KitchenSinkB bit of the generator output
+	awgEnable = 0x40
+)
+
+// ErrNoGenerator is returned by the Generator methods on models without
+// a waveform generator.
+var ErrNoGenerator = errors.New("No waveform generator on this model")
+
+// Gen returns the waveform generator of the BitScope. Its settings are
+// kept while the Scope is open; the default is a 1 kHz sine of 1 Vpp
+// around 0 V.
+func (bs *Scope) Gen() *Generator {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if bs.gen == nil {
+		bs.gen = &Generator{bs: bs, vpp: 1, size: awgSize}
+		bs.gen.setFrequency(1000)
+	}
+	return bs.gen
+}
+
+// Start starts the output of the generator.
+func (g *Generator) Start() error {
+
+	g.bs.mu.Lock()
+	defer g.bs.mu.Unlock()
+
+	if g.bs.Model != "bs10" {
+		return ErrNoGenerator
+	}
+
+	g.running = true
+	if err := g.program(); err != nil {
+		g.running = false
+		return err
+	}
+	return nil
+}
+
+// Stop stops the output of the generator.
+func (g *Generator) Stop() error {
+
+	g.bs.mu.Lock()
+	defer g.bs.mu.Unlock()
+
+	if !g.running {
+		return nil
+	}
+	g.running = false
+
+	b := append(reg(RegAwgCmd, awgStop), 'Y')
+	b = append(b, reg(RegKitchenSinkB, g.bs.kitchenSinkB())...)
+	_, err := g.bs.set("awg", b)
+	return err
+}
+
+// Running tells whether the generator has been started.
+func (g *Generator) Running() bool {
+
+	g.bs.mu.Lock()
+	defer g.bs.mu.Unlock()
+
+	return g.running
+}
+
+// SetFrequency sets the frequency of the waveform (one pass through the
+// table) to the closest one that the clock divider can produce, and
+// returns it. The default sine table is resized for the best match.
+func (g *Generator) SetFrequency(hz float64) (float64, error) {
+
+	g.bs.mu.Lock()
+	defer g.bs.mu.Unlock()
+
+	if err := g.setFrequency(hz); err != nil {
+		return 0, err
+	}
+	return g.freq, g.update()
+}
+
+func (g *Generator) setFrequency(hz float64) error {
+
+	// The size of the sine synthesized by the VM is free
+	if g.table == nil {
+		g.size = uint(awgTableSize(hz))
+	}
+
+	ticks, err := awgTicks(hz, g.size)
+	if err != nil {
+		return err
+	}
+
+	g.ticks = ticks
+	g.freq = masterClock / float64(ticks*g.size)
+	return nil
+}
+
+// awgTicks returns the master clock ticks per sample of a table of n
+// samples played at hz.
+func awgTicks(hz float64, n uint) (uint, error) {
+
+	if hz <= 0 {
+		return 0, errors.New("Generator frequency out of range")
+	}
+	ticks := math.Round(masterClock / (hz * float64(n)))
+	if ticks < 1 || ticks > 0xffff {
+		return 0, errors.New("Generator frequency out of range")
+	}
+	return uint(ticks), nil
+}
+
+// setTable writes a waveform table to the generator memory. The table is
+// used by the following calls to program.
+func (g *Generator) setTable(t []byte) error {
+
+	if len(t) == 0 || len(t) > awgMemory {
+		return errors.New("Invalid waveform table size")
+	}
+
+	p := vm.New().Set(uint8(RegAwgIndex), 0, 2)
+	for _, v := range t {
+		p.Write(uint(v))
+	}
+	if _, err := g.bs.set("awgtable", p.Bytes()); err != nil {
+		return err
+	}
+
+	g.table = append([]byte(nil), t...)
+	g.size = uint(len(t))
+	return nil
+}
+
+// SetAmplitude sets the peak to peak amplitude of the output, in volts.
+func (g *Generator) SetAmplitude(vpp float64) error {
+
+	g.bs.mu.Lock()
+	defer g.bs.mu.Unlock()
+
+	if err := checkAwgLevels(vpp, g.offset); err != nil {
+		return err
+	}
+	g.vpp = vpp
+	return g.update()
+}
+
+// SetOffset sets the DC offset of the output, in volts.
+func (g *Generator) SetOffset(v float64) error {
+
+	g.bs.mu.Lock()
+	defer g.bs.mu.Unlock()
+
+	if err := checkAwgLevels(g.vpp, v); err != nil {
+		return err
+	}
+	g.offset = v
+	return g.update()
+}
+
+// checkAwgLevels checks that an output with the given amplitude and offset
+// stays within the range of the generator.
+func checkAwgLevels(vpp, offset float64) error {
+	if vpp < 0 || math.Abs(offset)+vpp/2 > awgRange/2 {
+		return errors.New("Generator output out of range")
+	}
+	return nil
+}
+
+// update reprograms the generator if it is running.
+func (g *Generator) update() error {
+	if !g.running {
+		return nil
+	}
+	return g.program()
+}
+
+// program sends the settings of the generator and starts it.
+func (g *Generator) program() error {
+
+	var b []byte
+	b = append(b, reg(RegAwgAddress, 0)...)
+	b = append(b, reg(RegAwgSize, g.size)...)
+	b = append(b, reg(RegAwgModulo, g.size)...)
+	if g.table == nil {
+		b = append(b, reg(RegAwgMode, 0)...)
+		b = append(b, reg(RegAwgCmd, awgSynthesize)...)
+		b = append(b, 'Y')
+	}
+
+	b = append(b, reg(RegAwgClock, g.ticks)...)
+	b = append(b, reg(RegAwgLevel, uint(math.Round(g.vpp/awgRange*0xffff)))...)
+	b = append(b, reg(RegAwgOffset, uint(math.Round((g.offset/awgRange+0.5)*0xffff)))...)
+	b = append(b, reg(RegKitchenSinkB, g.bs.kitchenSinkB())...)
+	b = append(b, reg(RegAwgCmd, awgGenerate)...)
+	b = append(b, 'Y')
+
+	_, err := g.bs.set("awg", b)
+	return err
+}
+
+// kitchenSinkB returns the value of KitchenSinkB: the analog filter, and
+// the generator output if it is running.
+func (bs *Scope) kitchenSinkB() uint {
+	if bs.gen != nil && bs.gen.running {
+		return 0x80 | awgEnable
+	}
+	return 0x80
+}
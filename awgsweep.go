@@ -0,0 +1,137 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// SweepConfig describes a frequency sweep of the generator.
+type SweepConfig struct {
+	// First and last frequency, in Hz
+	Start, Stop float64
+	// Total time of the sweep
+	Duration time.Duration
+	// Number of frequencies (at least 2)
+	Steps int
+	// Logarithmic instead of linear spacing of the frequencies
+	Log bool
+}
+
+// SweepStep is a frequency of a sweep, as set in the generator, or the
+// error that ended the sweep.
+type SweepStep struct {
+	Freq float64
+	Err  error
+}
+
+// Sweep steps the frequency of the generator from cfg.Start to cfg.Stop,
+// in a goroutine that reprograms it, and starts it if needed. Each
+// frequency is sent on the returned channel once it is set, and kept for
+// Duration/Steps, or until it has been received if that takes longer. For
+// measurements at each frequency, see Bode.
+//
+// The channel is closed at the end of the sweep, when ctx is done, or
+// after a step with an error. The generator keeps running at the last
+// frequency.
+func (g *Generator) Sweep(ctx context.Context, cfg SweepConfig) <-chan SweepStep {
+
+	ch := make(chan SweepStep)
+
+	go func() {
+		defer close(ch)
+
+		if cfg.Steps < 2 || cfg.Start <= 0 || cfg.Stop <= 0 {
+			select {
+			case ch <- SweepStep{Err: errInvalidSweep}:
+			case <-ctx.Done():
+			}
+			return
+		}
+		dt := cfg.Duration / time.Duration(cfg.Steps)
+
+		for i := 0; i < cfg.Steps && ctx.Err() == nil; i++ {
+
+			f, err := g.step(cfg.frequency(i))
+
+			select {
+			case ch <- SweepStep{f, err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+
+			select {
+			case <-time.After(dt):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+var errInvalidSweep = errors.New("Invalid sweep")
+
+// frequency returns the frequency of step i of the sweep.
+func (cfg *SweepConfig) frequency(i int) float64 {
+	x := float64(i) / float64(cfg.Steps-1)
+	if cfg.Log {
+		return cfg.Start * math.Pow(cfg.Stop/cfg.Start, x)
+	}
+	return cfg.Start + (cfg.Stop-cfg.Start)*x
+}
+
+// step sets the frequency of the generator, and starts it if needed.
+func (g *Generator) step(hz float64) (float64, error) {
+
+	g.bs.mu.Lock()
+	defer g.bs.mu.Unlock()
+
+	if g.bs.Model != "bs10" {
+		return 0, ErrNoGenerator
+	}
+	if err := g.setFrequency(hz); err != nil {
+		return 0, err
+	}
+	g.running = true
+	return g.freq, g.program()
+}
+
+// Burst plays n cycles of the waveform and stops the generator, for a
+// stimulus that starts and ends on demand: each call is a burst. The
+// duration is timed by the host, so it is only accurate to a few
+// milliseconds, and the number of cycles only for slow waveforms.
+func (g *Generator) Burst(ctx context.Context, n int) error {
+
+	if n <= 0 {
+		return errors.New("Invalid burst length")
+	}
+
+	g.bs.mu.Lock()
+	f := g.freq
+	g.bs.mu.Unlock()
+
+	if err := g.Start(); err != nil {
+		return err
+	}
+
+	t := time.NewTimer(time.Duration(float64(n) / f * float64(time.Second)))
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+
+	if err := g.Stop(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,154 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"errors"
+	"math"
+)
+
+// Sine starts the generator with a sine wave of frequency hz and vpp volts
+// peak to peak, around the offset set with SetOffset.
+func (g *Generator) Sine(hz, vpp float64) error {
+	return g.preset(hz, vpp, func(i, n int) float64 {
+		return (1 + math.Sin(2*math.Pi*float64(i)/float64(n))) / 2
+	})
+}
+
+// Square starts the generator with a square wave with a duty cycle of 50%.
+func (g *Generator) Square(hz, vpp float64) error {
+	return g.preset(hz, vpp, func(i, n int) float64 {
+		if i < n/2 {
+			return 1
+		}
+		return 0
+	})
+}
+
+// Triangle starts the generator with a triangle wave.
+func (g *Generator) Triangle(hz, vpp float64) error {
+	return g.preset(hz, vpp, func(i, n int) float64 {
+		return 1 - math.Abs(2*float64(i)/float64(n)-1)
+	})
+}
+
+// Ramp starts the generator with a rising sawtooth wave.
+func (g *Generator) Ramp(hz, vpp float64) error {
+	return g.preset(hz, vpp, func(i, n int) float64 {
+		return float64(i) / float64(n-1)
+	})
+}
+
+// DC sets the generator output to a constant level of v volts. It replaces
+// the offset set with SetOffset.
+func (g *Generator) DC(v float64) error {
+
+	g.bs.mu.Lock()
+	defer g.bs.mu.Unlock()
+
+	if g.bs.Model != "bs10" {
+		return ErrNoGenerator
+	}
+	if err := checkAwgLevels(0, v); err != nil {
+		return err
+	}
+
+	return g.start([]byte{128}, 1000, 0, v)
+}
+
+// preset synthesizes a table with the shape f (a function of the sample
+// index i of n, from 0 to 1) and starts the generator with it.
+func (g *Generator) preset(hz, vpp float64, f func(i, n int) float64) error {
+
+	g.bs.mu.Lock()
+	defer g.bs.mu.Unlock()
+
+	if g.bs.Model != "bs10" {
+		return ErrNoGenerator
+	}
+	if err := checkAwgLevels(vpp, g.offset); err != nil {
+		return err
+	}
+
+	n := awgTableSize(hz)
+	t := make([]byte, n)
+	for i := range t {
+		t[i] = byte(math.Round(255 * f(i, n)))
+	}
+	return g.start(t, hz, vpp, g.offset)
+}
+
+// awgTableSize returns the size of the table, at most the default size,
+// that gives the frequency closest to hz.
+func awgTableSize(hz float64) int {
+
+	best, e := awgSize, math.Inf(1)
+	for n := awgSize; n >= 16; n-- {
+		ticks, err := awgTicks(hz, uint(n))
+		if err != nil {
+			continue
+		}
+		f := masterClock / float64(ticks*uint(n))
+		if d := math.Abs(f - hz); d < e {
+			best, e = n, d
+		}
+	}
+	return best
+}
+
+// start loads the table t and starts the generator with the given
+// settings, which are kept only if it succeeds.
+func (g *Generator) start(t []byte, hz, vpp, offset float64) error {
+
+	if _, err := awgTicks(hz, uint(len(t))); err != nil {
+		return err
+	}
+	if err := g.setTable(t); err != nil {
+		return err
+	}
+
+	g.setFrequency(hz)
+	g.vpp, g.offset = vpp, offset
+	g.running = true
+
+	if err := g.program(); err != nil {
+		g.running = false
+		return err
+	}
+	return nil
+}
+
+// Load starts the generator with an arbitrary waveform: samples in volts,
+// played in a loop at rate samples per second. The samples are quantized
+// to the 8 bits of the waveform memory, and their range mapped onto the
+// amplitude and offset of the output, which replace those set before.
+func (g *Generator) Load(samples []float64, rate float64) error {
+
+	g.bs.mu.Lock()
+	defer g.bs.mu.Unlock()
+
+	if g.bs.Model != "bs10" {
+		return ErrNoGenerator
+	}
+	if len(samples) == 0 || len(samples) > awgMemory {
+		return errors.New("Invalid waveform table size")
+	}
+
+	lo, hi := samples[0], samples[0]
+	for _, v := range samples {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	vpp, offset := hi-lo, (hi+lo)/2
+	if err := checkAwgLevels(vpp, offset); err != nil {
+		return err
+	}
+
+	t := make([]byte, len(samples))
+	for i, v := range samples {
+		t[i] = 128
+		if vpp > 0 {
+			t[i] = byte(math.Round(255 * (v - lo) / vpp))
+		}
+	}
+	return g.start(t, rate/float64(len(t)), vpp, offset)
+}
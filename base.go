@@ -3,64 +3,246 @@
 package bitscope
 
 import (
+	"bytes"
+	"context"
 	"errors"
-	// "fmt"
-	"github.com/pkg/term"
+	"io"
 	"strings"
+	"sync"
 	"time"
 )
 
+// baud is the default speed of the serial link with the BitScope.
+const baud = 115200
+
+// Options holds the serial port settings used by OpenWith. Zero values
+// select the defaults.
+type Options struct {
+	// Link speed in baud (default 115200)
+	Baud int
+	// Time after which a read without data returns (default: wait
+	// for at least one byte)
+	ReadTimeout time.Duration
+	// Leave the line discipline as it is instead of setting raw mode,
+	// for adapters that are configured externally
+	NoRaw bool
+	// Re-open the device when the connection is lost (see Reconnect)
+	Reconnect bool
+	// Number of times a command is repeated after a transient error, and
+	// the delay before the first retry (doubled at each retry)
+	Retries      int
+	RetryBackoff time.Duration
+	// Number of bytes re-read after each dump chunk to check its
+	// integrity (see SetVerifyDump)
+	VerifyDump uint
+}
+
+// Transport is the link over which the BitScope VM is accessed. Open uses a
+// serial port, but any other transport (TCP, mock, replay) can be given to
+// New.
+//
+// SetDeadline sets the time after which a blocked Read returns, with or
+// without data. A zero value means that Read waits for at least one byte.
+// A Read that returns because of the deadline, without data, must return
+// (0, nil): errors, including io.EOF, are taken as a lost connection.
+type Transport interface {
+	Read(b []byte) (int, error)
+	Write(b []byte) (int, error)
+	SetDeadline(t time.Time) error
+	Close() error
+}
+
+// eofTimeout adapts a transport whose Read returns io.EOF when a deadline
+// or read timeout expires without data, as a POSIX tty does, to the
+// contract of Transport. Without a deadline, io.EOF is passed on (a
+// hangup).
+type eofTimeout struct {
+	Transport
+	// Read timeout in effect without a deadline
+	timeout time.Duration
+	timed   bool
+}
+
+func (t *eofTimeout) SetDeadline(d time.Time) error {
+	t.timed = !d.IsZero() || t.timeout > 0
+	return t.Transport.SetDeadline(d)
+}
+
+func (t *eofTimeout) Read(b []byte) (int, error) {
+	n, err := t.Transport.Read(b)
+	if n == 0 && err == io.EOF && t.timed {
+		return 0, nil
+	}
+	return n, err
+}
+
+// Scope is a connection to a BitScope instrument.
 type Scope struct {
-	tty *term.Term
+	tty Transport
+	// Speed of the link in baud, for the transfer timeouts
+	speed int
 	// The ID string returned by the BitScope
 	ID string
 	// The model of the attached scope ('bs10' or 'bs05')
-	Model   string
-	trigSrc uint
+	Model      string
+	trigSrc    uint
+	trigLevel  uint
+	trigLogic  uint
+	trigMask   uint
+	spock      uint
+	trigSource TriggerSource
+	hyst       uint
+	delay      uint
+	timing     [3]uint
+	trigEvents chan<- TriggerEvent
+	gen        *Generator
+	leds       bool
+
+	clockScale  uint
+	clockTicks  uint
+	vrange      float64
+	source      [2]Source
+	softAC      [2]bool
+	attenuation [2]float64
+	prelude     uint
+
+	logger   Logger
+	logLevel LogLevel
+	ptrace   *protocolTrace
+
+	// mu serializes the commands sent by different goroutines; tracing is
+	// set while a trace is waiting for its trigger, and stopped records
+	// whether ForceTrigger or TraceTerminate ended the wait
+	mu      sync.Mutex
+	tracing int32
+	stopped int32
+
+	resyncing bool
+
+	retries int
+	backoff time.Duration
+
+	// Reconnection policy and state (see reconnect.go)
+	reopen       func() (Transport, error)
+	config       []setting
+	reconnecting bool
+	verify       uint
+	format       DumpFormat
+
+	// Acquisition started by Arm, if any
+	armed *armed
 }
 
+// BitScope is the former name of Scope.
+//
+// Deprecated: use Scope.
+type BitScope = Scope
+
 // Open opens a connection to a BitScope instrument.
 //
 // If the ID string returned by the BitScope is not recognized as one of the
 // supported ones, an error is returned.
+//
+// The device can be given as a full path or port name, or as the port number
+// only ("0" is /dev/ttyUSB0 on Linux, "3" is COM3 on Windows). An empty
+// string selects the first port.
 func Open(dev string) (*Scope, error) {
+	return OpenWith(dev, nil)
+}
 
-	const base string = "/dev/ttyUSB"
+// OpenContext opens a connection to a BitScope instrument as Open does, but
+// gives up waiting for the instrument to answer when ctx is done.
+func OpenContext(ctx context.Context, dev string) (*Scope, error) {
+	return OpenWithContext(ctx, dev, nil)
+}
 
-	switch len(dev) {
+// OpenWith opens a connection to a BitScope instrument as Open does, with
+// non-default serial port settings. A nil opt selects the defaults.
+func OpenWith(dev string, opt *Options) (*Scope, error) {
+	return OpenWithContext(context.Background(), dev, opt)
+}
 
-	case 0:
-		dev = base + "0"
-	case 1:
-		fallthrough
-	case 2:
-		dev = base + dev
+// OpenWithContext is OpenWith with a context, as OpenContext.
+func OpenWithContext(ctx context.Context, dev string, opt *Options) (*Scope, error) {
 
+	if opt == nil {
+		opt = &Options{}
 	}
 
-	tty, err := term.Open(dev)
+	dev = portName(dev)
+	tty, err := openSerial(dev, opt)
+
+	if err != nil {
+		return nil, err
+	}
 
+	bs, err := newScope(ctx, tty)
 	if err != nil {
 		return nil, err
 	}
 
-	tty.SetRaw()
+	if err = ctx.Err(); err != nil {
+		bs.Close()
+		return nil, err
+	}
+
+	if opt.Baud > 0 {
+		bs.speed = opt.Baud
+	}
+	bs.SetRetry(opt.Retries, opt.RetryBackoff)
+	bs.SetVerifyDump(opt.VerifyDump)
+
+	if opt.Reconnect {
+		o := *opt
+		bs.reopen = func() (Transport, error) { return openSerial(dev, &o) }
+	}
+
+	return bs, nil
+}
+
+// New returns a Scope that accesses a BitScope through the given transport.
+//
+// If the BitScope is not recognized as one of the supported models, the
+// transport is closed and an error is returned.
+func New(tty Transport) (*Scope, error) {
+	return newScope(context.Background(), tty)
+}
 
-	bs := Scope{tty, "", "", 0}
+func newScope(ctx context.Context, tty Transport) (*Scope, error) {
 
-	bs.ID = bs.Id()
-	if strings.HasPrefix(bs.ID, "BS0010") {
-		bs.Model = "bs10"
-	} else if strings.HasPrefix(bs.ID, "BS0005") {
-		bs.Model = "bs05"
-	} else {
+	bs := Scope{tty: tty, speed: baud, trigLevel: 0x68f5, trigLogic: 0x80, trigMask: 0x7f, spock: 0x21}
+
+	bs.ID = bs.id(ctx)
+	bs.Model = model(bs.ID)
+	if bs.Model == "" {
 		tty.Close()
-		return nil, errors.New("Unsupported model: " + bs.ID)
+		return nil, unsupportedError{bs.ID}
 	}
 
 	return &bs, nil
 }
 
+// unsupportedError is returned when a device does not answer with the ID of
+// a supported BitScope; id is empty if it did not answer at all.
+type unsupportedError struct {
+	id string
+}
+
+func (e unsupportedError) Error() string {
+	return "Unsupported model: " + e.id
+}
+
+// model returns the model name corresponding to an ID string, or an empty
+// string if the model is not supported.
+func model(id string) string {
+	if strings.HasPrefix(id, "BS0010") {
+		return "bs10"
+	} else if strings.HasPrefix(id, "BS0005") {
+		return "bs05"
+	}
+	return ""
+}
+
 // Close ends the connection to the BisScope
 func (bs *Scope) Close() error {
 	return bs.tty.Close()
@@ -71,187 +253,252 @@ func (bs *Scope) Close() error {
 // Use bs.ID instead of this function unless you want a to explicitly ask the
 // BitScope for its ID.
 func (bs *Scope) Id() string {
-	b, err := bs.call([]byte("?"))
-	if len(b) == 0 || err != nil {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.id(context.Background())
+}
+
+func (bs *Scope) id(ctx context.Context) string {
+	r, err := bs.exchange(ctx, []byte("?"), frame{lines: 1}, callTimeout)
+	if err != nil || len(r.lines) == 0 {
 		return ""
 	}
-	return strings.TrimSpace(string(b[1:]))
+	return r.lines[0]
 }
 
-// call sends data to the instrument and returns its response. Its waits a
-// fixed time of 2ms for the response to arrive.
-func (bs *Scope) call(b []byte) ([]byte, error) {
+// ErrTimeout is returned when the BitScope does not answer in time.
+var ErrTimeout = errors.New("Timeout waiting for the BitScope")
 
-	n, err := bs.tty.Write(b)
+// callTimeout is the time given to the BitScope to answer a command.
+const callTimeout = time.Second
 
-	if err != nil {
-		return nil, err
-	}
+// transferTimeout returns the time given to the BitScope to answer a
+// command with n bytes to transfer over the link (10 bits per byte).
+func (bs *Scope) transferTimeout(n int) time.Duration {
+	return callTimeout + time.Duration(n)*10*time.Second/time.Duration(bs.speed)
+}
 
-	// BUG: to do. For now this works for responses with < 256 bytes
+// call sends data to the instrument and returns its response: the echo of
+// the command. It returns as soon as the echo has been received.
+func (bs *Scope) call(b []byte) ([]byte, error) {
+	r, err := bs.exchangeRetry(context.Background(), b, frame{}, callTimeout)
+	return r.raw, err
+}
 
-	time.Sleep(time.Millisecond * 2)
+// callData sends a command that returns size bytes of binary data, and
+// returns that data (without the echo of the command).
+func (bs *Scope) callData(ctx context.Context, b []byte, size uint) ([]byte, error) {
 
-	r := make([]byte, 256)
-	n, err = bs.tty.Read(r)
-/*
-	var c byte
+	r, err := bs.exchangeRetry(ctx, b, frame{data: int(size)}, bs.transferTimeout(int(size)))
+	return r.data, err
+}
 
-	for i := 0; i < n; i++ {
+// call sends data to the instrument and returns its response. It waits until
+// it receives the specified number of CR terminated lines after the echo of
+// the command, or until ctx is done.
+func (bs *Scope) callCr(ctx context.Context, b []byte, cr int) ([]byte, error) {
+	r, err := bs.exchange(ctx, b, frame{lines: cr}, 0)
+	return r.raw, err
+}
 
-		c = r[i]
-		if c < 32 {
-			c = '_'
-		}
-		fmt.Printf("%c", c)
+// frame describes the response of the VM to a command: the echo of the
+// command, followed by binary data of known length and/or a number of CR
+// terminated text lines.
+type frame struct {
+	data  int
+	lines int
+}
 
-	}
-	fmt.Println("")
-*/
-	return r[0:n], err
+// response is a response of the VM, split according to its frame.
+type response struct {
+	// The whole response as received
+	raw []byte
+	// The echo of the command
+	echo []byte
+	// Binary data following the echo
+	data []byte
+	// Non-empty text lines following the data, without the CR
+	lines []string
 }
 
-// call sends data to the instrument and returns its response.
-func (bs *Scope) callWait(b []byte, ms int, bufSize uint) ([]byte, error) {
+// complete tells whether r holds the whole response to cmd.
+func (f frame) complete(cmd, r []byte) bool {
+	return f.size(cmd, r) >= 0
+}
 
-	n, err := bs.tty.Write(b)
+// size returns the length of the response to cmd at the start of r, or -1
+// if r does not hold the whole response.
+func (f frame) size(cmd, r []byte) int {
 
-	if err != nil {
-		return nil, err
+	n := len(cmd) + f.data
+	if len(r) < n {
+		return -1
+	}
+	if f.lines == 0 {
+		return n
 	}
 
-	// We want to block until a response is received (but not forever) and
-	// not rely on the message content to decide the end of this response.
-	//
-	// If we set the fd to non blocking, we may return from the call before
-	// we receive a byte. So, a time window is needed, but file reads don't
-	// have a timeout option.
-	//
-	// Ref: https://groups.google.com/d/msg/golang-nuts/QV-zn2JHNt4/-0YxnL7sBc8J
-	//
-	// BUG: to do. For now this works for responses with < 256 bytes
+	count := 0
+	empty := true
+
+	for i := n; i < len(r); i++ {
+		switch r[i] {
+		case '\r':
+			if !empty {
+				count++
+				if count == f.lines {
+					return i + 1
+				}
+			}
+			empty = true
+		case '\n', ' ':
+		default:
+			empty = false
+		}
+	}
 
-	time.Sleep(time.Millisecond * time.Duration(ms))
+	return -1
+}
 
-	r := make([]byte, bufSize)
-	n, err = bs.tty.Read(r)
+// parse splits the response r to cmd into its parts. Incomplete responses
+// are split as far as possible.
+func (f frame) parse(cmd, r []byte) *response {
 
-/*
-	var c byte
+	res := &response{raw: r}
 
-	for i := 0; i < n; i++ {
+	n := len(cmd)
+	if n > len(r) {
+		n = len(r)
+	}
+	res.echo, r = r[:n], r[n:]
+
+	n = f.data
+	if n > len(r) {
+		n = len(r)
+	}
+	res.data, r = r[:n], r[n:]
 
-		c = r[i]
-		if c < 32 {
-			c = '_'
+	for _, s := range strings.Split(string(r), "\r") {
+		s = strings.TrimSpace(s)
+		if s != "" {
+			res.lines = append(res.lines, s)
 		}
-		fmt.Printf("%c", c)
+	}
 
+	return res
+}
+
+// exchange writes cmd to the instrument and reads the response described by
+// f, until it is complete, ctx is done, or the timeout expires (0: no
+// timeout). Reads return as soon as data is available, so no time is wasted
+// waiting.
+//
+// A response is always returned, possibly incomplete in case of error. If
+// the echo differs from the command, ErrOutOfSync is returned.
+func (bs *Scope) exchange(ctx context.Context, cmd []byte, f frame, timeout time.Duration) (*response, error) {
+
+	if err := bs.checkArmed(); err != nil {
+		return f.parse(cmd, nil), err
+	}
+
+	if bs.ptrace == nil {
+		return bs.transfer(ctx, cmd, f, timeout)
 	}
-	fmt.Println("")
-*/
-	return r[0:n], err
+
+	t := time.Now()
+	r, err := bs.transfer(ctx, cmd, f, timeout)
+	bs.ptrace.log(t, cmd, r.raw, err)
+	return r, err
 }
 
-// call sends data to the instrument and returns its response. It waits until
-// it receives the specified number of CR characters (ASCII 13).
-func (bs *Scope) callCr(b []byte, cr int, bufSize uint) ([]byte, error) {
+// transfer does the work of exchange.
+func (bs *Scope) transfer(ctx context.Context, cmd []byte, f frame, timeout time.Duration) (*response, error) {
+
+	if bs.logLevel >= LogCommands {
+		bs.logger.Printf("bitscope: TX %q", cmd)
+	}
 
-	n, err := bs.tty.Write(b)
+	n, err := bs.tty.Write(cmd)
 
 	if err != nil {
-		return nil, err
+		return f.parse(cmd, nil), bs.lost(err)
 	}
 
-	if n != len(b) {
-		return nil, errors.New("Not all bytes were written")
+	if n != len(cmd) {
+		return f.parse(cmd, nil), errors.New("Not all bytes were written")
 	}
 
-	// Read until the specified number of CRs have been read.
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 
-	var res []byte
-	r := make([]byte, bufSize)
+	res := make([]byte, 0, len(cmd)+f.data)
+	r := make([]byte, 4096)
 
-	for {
+	// Reads return regularly to check ctx
+	defer bs.tty.SetDeadline(time.Time{})
 
-		n, err = bs.tty.Read(r)
-		if err != nil {
-			break
-		}
+	for !f.complete(cmd, res) {
 
-		if n > 0 {
-			res = append(res, r[0:n]...)
-		}
-
-		// Count CR's
-		n := 0
-		for i := 0; i < len(res); i++ {
-			if res[i] == 13 {
-				n++
+		if err = ctx.Err(); err != nil {
+			if err == context.DeadlineExceeded && timeout > 0 {
+				// A late response would be taken for the response
+				// to the next command
+				err = ErrTimeout
+				bs.resync()
 			}
-		}
-		if n >= cr {
 			break
 		}
-	}
 
-/*
-	var c byte
-	for i := 0; i < len(res); i++ {
+		bs.tty.SetDeadline(pollDeadline(ctx))
 
-		c = res[i]
-		if c < 32 {
-			c = '_'
+		n, err = bs.tty.Read(r)
+		if err != nil {
+			break
 		}
-		fmt.Printf("%c", c)
 
+		res = append(res, r[0:n]...)
 	}
-	fmt.Println("")
-*/
-	return res, err
-}
 
-// hex converts a small unsigned integer (0-255) into its hex alphanumeric
-// representation, at a speficied position in the array given.
+	if bs.logLevel >= LogRaw {
+		bs.logger.Printf("bitscope: RX %q", res)
+	}
 
-const h string = "0123456789abcdef"
+	resp := f.parse(cmd, res)
 
-func hex1(n uint, b []byte, offset int) {
-	n = n & 0xff
+	if err == nil && !bytes.Equal(resp.echo, cmd) {
+		bs.resync()
+		return resp, ErrOutOfSync
+	}
 
-	b[offset+1] = h[n&15]
-	b[offset] = h[(n>>4)&15]
+	return resp, bs.lost(err)
 }
 
-func hex2(n uint, b []byte, offset int) {
-	n = n & 0xffff
+// pollDeadline returns the deadline for a read that should return in time to
+// check whether ctx is done.
+func pollDeadline(ctx context.Context) time.Time {
 
-	b[offset+1] = h[n&15]
-	b[offset] = h[(n>>4)&15]
-	b[offset+4] = h[(n>>8)&15]
-	b[offset+3] = h[(n>>12)&15]
+	const poll = 100 * time.Millisecond
+
+	t := time.Now().Add(poll)
+	if d, ok := ctx.Deadline(); ok && d.Before(t) {
+		return d
+	}
+	return t
 }
 
-func hex3(n uint, b []byte, offset int) {
-	n = n & 0xffffff
+// hex converts a small unsigned integer (0-255) into its hex alphanumeric
+// representation, at a speficied position in the array given.
 
-	b[offset+1] = h[n&15]
-	b[offset] = h[(n>>4)&15]
-	b[offset+4] = h[(n>>8)&15]
-	b[offset+3] = h[(n>>12)&15]
-	b[offset+7] = h[(n>>16)&15]
-	b[offset+6] = h[(n>>20)&15]
-}
+const h string = "0123456789abcdef"
 
-func hex4(n uint, b []byte, offset int) {
-	n = n & 0xffffffff
+func hex1(n uint, b []byte, offset int) {
+	n = n & 0xff
 
 	b[offset+1] = h[n&15]
 	b[offset] = h[(n>>4)&15]
-	b[offset+4] = h[(n>>8)&15]
-	b[offset+3] = h[(n>>12)&15]
-	b[offset+7] = h[(n>>16)&15]
-	b[offset+6] = h[(n>>20)&15]
-	b[offset+10] = h[(n>>24)&15]
-	b[offset+9] = h[(n>>32)&15]
 }
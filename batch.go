@@ -0,0 +1,85 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+
+	"bitscope/vm"
+)
+
+// Batch accumulates VM commands, typically register writes, that are sent
+// to the BitScope in a single write by Flush. The VM executes concatenated
+// commands in sequence, so a batch costs one round trip instead of one per
+// command.
+//
+//	b := bs.Batch()
+//	b.Set(0x7b, 0x80, 1)
+//	b.Set(0x22, 0, 4)
+//	err := b.Flush()
+//
+// Commands that return data other than their echo ('D', 'A', '?') should
+// not be batched.
+type Batch struct {
+	bs  *Scope
+	buf []byte
+}
+
+// Batch returns an empty command batch for this Scope.
+func (bs *Scope) Batch() *Batch {
+	return &Batch{bs: bs}
+}
+
+// Set adds the write of a little endian value of size bytes (1 to 4) to
+// the consecutive registers starting at reg.
+func (b *Batch) Set(reg, value uint, size int) *Batch {
+	b.buf = append(b.buf, regCmd(reg, value, size)...)
+	return b
+}
+
+// WriteReg adds the write of a value to a register, with the width given
+// by its Size.
+func (b *Batch) WriteReg(r Register, value uint) *Batch {
+	b.buf = append(b.buf, reg(r, value)...)
+	return b
+}
+
+// Cmd adds raw VM commands to the batch.
+func (b *Batch) Cmd(cmd string) *Batch {
+	b.buf = append(b.buf, cmd...)
+	return b
+}
+
+// Len returns the number of bytes pending in the batch.
+func (b *Batch) Len() int {
+	return len(b.buf)
+}
+
+// Flush sends the pending commands and checks their echo. The batch is
+// empty afterwards, also in case of error.
+func (b *Batch) Flush() error {
+	return b.FlushContext(context.Background())
+}
+
+// FlushContext is Flush with a context.
+func (b *Batch) FlushContext(ctx context.Context) error {
+
+	if len(b.buf) == 0 {
+		return nil
+	}
+	cmd := b.buf
+	b.buf = nil
+
+	bs := b.bs
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	_, err := bs.exchangeRetry(ctx, cmd, frame{}, bs.transferTimeout(len(cmd)))
+	return err
+}
+
+// regCmd returns the VM command that writes a little endian value of size
+// bytes to the registers starting at reg: "aa@LLzHHs" for two bytes.
+func regCmd(reg, value uint, size int) []byte {
+	return vm.New().Set(uint8(reg), value, size).Bytes()
+}
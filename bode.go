@@ -0,0 +1,140 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"math"
+	"math/cmplx"
+	"time"
+)
+
+// BodePoint is the response of a circuit at one frequency.
+type BodePoint struct {
+	// Frequency in Hz, as set in the generator
+	Freq float64
+	// Ratio of the amplitudes of CHB and CHA, and the same in dB
+	Gain   float64
+	GainDB float64
+	// Phase of CHB relative to CHA, in degrees (-180 to 180)
+	Phase float64
+}
+
+// Samples per channel, and per period of the signal, of the captures of
+// Bode.
+const (
+	bodeSamples = 1024
+	bodePeriod  = 64
+)
+
+// Bode measures the frequency response of a circuit driven by the
+// generator: CHA must see its input and CHB its output. The generator is
+// stepped through the frequencies of cfg as with Sweep (Duration is
+// ignored); at each one both channels are captured, at a sample rate chosen
+// for the frequency, and the amplitude and phase of their fundamentals are
+// compared. The vertical range must suit both signals. The time base and
+// the trigger timing are restored after each capture.
+func (g *Generator) Bode(ctx context.Context, cfg SweepConfig) ([]BodePoint, error) {
+
+	if cfg.Steps < 2 || cfg.Start <= 0 || cfg.Stop <= 0 {
+		return nil, errInvalidSweep
+	}
+
+	var res []BodePoint
+
+	for i := 0; i < cfg.Steps; i++ {
+
+		f, err := g.step(cfg.frequency(i))
+		if err != nil {
+			return res, err
+		}
+
+		// Let the circuit settle
+		settle := time.Duration(10 / f * float64(time.Second))
+		if settle < time.Millisecond {
+			settle = time.Millisecond
+		}
+		select {
+		case <-time.After(settle):
+		case <-ctx.Done():
+			return res, ctx.Err()
+		}
+
+		p, err := g.bs.bodePoint(ctx, f)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+// bodePoint captures both channels and compares their fundamentals at hz.
+func (bs *Scope) bodePoint(ctx context.Context, hz float64) (BodePoint, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	defer bs.restoreTiming()()
+
+	// Both channels share the sample clock (chop mode)
+	rate, err := bs.setSampleRate(math.Min(2*hz*bodePeriod, masterClock/minTicks))
+	if err != nil {
+		return BodePoint{}, err
+	}
+
+	// Timeout after 1 tick, so that the trace does not wait for a trigger
+	if err = bs.triggerTiming(0, 0, 1); err != nil {
+		return BodePoint{}, err
+	}
+	if _, err = bs.traceCapture(ctx, captureDual, 0, 2*bodeSamples, 0); err != nil {
+		return BodePoint{}, err
+	}
+	d, err := bs.dump(ctx, 2*bodeSamples)
+	if err != nil {
+		return BodePoint{}, err
+	}
+	a, b := deinterleave(d)
+
+	// A whole number of periods, to avoid leakage
+	w := 2 * math.Pi * hz / (rate / 2)
+	n := int(math.Floor(float64(len(a))*w/(2*math.Pi)) * 2 * math.Pi / w)
+	if n == 0 || n > len(b) {
+		n = len(b)
+	}
+
+	fa := tone(a[:n], w)
+	fb := tone(b[:n], w) * complex(bs.probe(1)/bs.probe(0), 0)
+
+	p := BodePoint{Freq: hz}
+	if cmplx.Abs(fa) == 0 {
+		return p, nil
+	}
+	r := fb / fa
+
+	// CHB is sampled one sample clock after CHA
+	phase := cmplx.Phase(r) - w/2
+	phase = math.Remainder(phase, 2*math.Pi)
+
+	p.Gain = cmplx.Abs(r)
+	p.GainDB = 20 * math.Log10(p.Gain)
+	p.Phase = phase * 180 / math.Pi
+	return p, nil
+}
+
+// tone returns the complex amplitude of the component of angular frequency
+// w (radians per sample) of the samples, without their mean.
+func tone(x []byte, w float64) complex128 {
+
+	mean := 0.0
+	for _, c := range x {
+		mean += float64(c)
+	}
+	mean /= float64(len(x))
+
+	var s complex128
+	for k, c := range x {
+		s += complex(float64(c)-mean, 0) * cmplx.Exp(complex(0, -w*float64(k)))
+	}
+	return s * complex(2/float64(len(x)), 0)
+}
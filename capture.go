@@ -0,0 +1,93 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import "context"
+
+// Trace modes (register TraceMode)
+const (
+	traceAnalog     = 0
+	traceMixed      = 1
+	traceAnalogChop = 2
+	traceFrequency  = 8
+	traceLogic      = 14
+	traceMacro      = 18
+)
+
+// Buffer modes (register BufferMode)
+const (
+	bufferSingle = 0
+	bufferChop   = 1
+	bufferDual   = 2
+	bufferMacro  = 4
+)
+
+// capture selects what a trace acquires: the trace and buffer modes, and
+// the analog (bit 0: CHA, bit 1: CHB) and logic channels that are enabled.
+type capture struct {
+	traceMode  uint
+	bufferMode uint
+	analog     uint
+	digital    uint
+}
+
+var (
+	captureAnalog = capture{traceAnalog, bufferSingle, 1, 0}
+	captureDual   = capture{traceAnalogChop, bufferChop, 3, 0}
+	captureLogic  = capture{traceLogic, bufferSingle, 0, 0xff}
+	captureMixed  = capture{traceMixed, bufferChop, 1, 0xff}
+	captureMacro  = capture{traceMacro, bufferMacro, 1, 0}
+	captureFreq   = capture{traceFrequency, bufferSingle, 3, 0}
+)
+
+// bufferSize is the size of the sample buffer, in bytes.
+const bufferSize = 12 * 1024
+
+/* -------------------------------------------------------------------------
+   Dual channel
+   -------------------------------------------------------------------------*/
+
+// DualTrace is Trace for both analog channels at once. The channels are
+// sampled alternately (chop mode), so that each gets half the sample rate;
+// pre and post are numbers of samples per channel.
+func (bs *Scope) DualTrace(pre, post, delay uint) ([]byte, error) {
+	return bs.DualTraceContext(context.Background(), pre, post, delay)
+}
+
+// DualTraceContext is DualTrace with a context (see TraceContext).
+func (bs *Scope) DualTraceContext(ctx context.Context, pre, post, delay uint) ([]byte, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.traceCapture(ctx, captureDual, 2*pre, 2*post, delay)
+}
+
+// DualDump reads size samples of each channel after a DualTrace, and
+// returns them separately.
+func (bs *Scope) DualDump(size uint) (a, b []byte, err error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	d, err := bs.dump(context.Background(), 2*size)
+
+	a, b = deinterleave(d)
+	return a, b, err
+}
+
+// deinterleave splits a buffer of alternating samples.
+func deinterleave(d []byte) (even, odd []byte) {
+
+	even = make([]byte, 0, (len(d)+1)/2)
+	odd = make([]byte, 0, len(d)/2)
+
+	for i, c := range d {
+		if i%2 == 0 {
+			even = append(even, c)
+		} else {
+			odd = append(odd, c)
+		}
+	}
+	return even, odd
+}
@@ -0,0 +1,49 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"errors"
+	"math"
+)
+
+// ClockOut produces a square wave of frequency hz on the clock output pin
+// of the POD of the BS10, with the high part taking the fraction duty of
+// the period. The clock generator counts ticks of the master clock for the
+// high (ClockRise) and low (ClockFall) parts, so hz ranges from about
+// 305 Hz to 20 MHz, and the frequency and duty cycle actually set are
+// returned. A frequency of 0 stops the output.
+func (bs *Scope) ClockOut(hz, duty float64) (float64, float64, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if bs.Model != "bs10" {
+		return 0, 0, errors.New("Clock output is only available on the BS10")
+	}
+
+	if hz == 0 {
+		_, err := bs.set("clock", reg(RegClockControl, 0))
+		return 0, 0, err
+	}
+	if hz < 0 || duty <= 0 || duty >= 1 {
+		return 0, 0, errors.New("Invalid clock output")
+	}
+
+	period := math.Round(masterClock / hz)
+	high := math.Round(period * duty)
+	low := period - high
+	if high < 1 || low < 1 || high > 0xffff || low > 0xffff {
+		return 0, 0, errors.New("Clock output out of range")
+	}
+
+	var b []byte
+	b = append(b, reg(RegClockRise, uint(high))...)
+	b = append(b, reg(RegClockFall, uint(low))...)
+	b = append(b, reg(RegClockControl, 1)...)
+
+	if _, err := bs.set("clock", b); err != nil {
+		return 0, 0, err
+	}
+	return masterClock / period, high / period, nil
+}
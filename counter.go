@@ -0,0 +1,28 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import "context"
+
+// CounterRead returns the event counter of the VM (the 24 bit sample
+// counter). During a trace it counts the events that clock the trace: ADC
+// clock events (samples taken) in the sampling trace modes, and rising
+// edges at the comparator output in frequency mode (see MeasureFrequency).
+// Its value after a trace gives event rates without a dump.
+func (bs *Scope) CounterRead() (uint, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.readReg(context.Background(), RegSampleCounter)
+}
+
+// CounterReset sets the event counter to zero.
+func (bs *Scope) CounterReset() error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	_, err := bs.call(reg(RegSampleCounter, 0))
+	return err
+}
@@ -0,0 +1,71 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrCursor is returned when a cursor falls outside of a trace.
+var ErrCursor = errors.New("Cursor outside of the trace")
+
+// Cursors is the reading of a pair of cursors on a trace: their times
+// (see Trace.Time) and the values of the trace there (see Trace.Volts),
+// and the differences between the second and the first.
+type Cursors struct {
+	T1, T2 float64
+	V1, V2 float64
+	// T2-T1, 1/(T2-T1) (Inf if the times are equal) and V2-V1
+	DeltaT, Frequency, DeltaV float64
+}
+
+// TimeCursors reads cursors placed at times t1 and t2, in seconds after the
+// trigger event. The values are interpolated between samples (see
+// LinearAt).
+func (t *Trace) TimeCursors(t1, t2 float64) (Cursors, error) {
+
+	c := Cursors{T1: t1, T2: t2, V1: t.LinearAt(t1), V2: t.LinearAt(t2)}
+	if math.IsNaN(c.V1) || math.IsNaN(c.V2) {
+		return c, ErrCursor
+	}
+	return c.deltas(), nil
+}
+
+// VoltageCursors reads cursors placed at values v1 and v2, at the first
+// times the trace crosses them (interpolated between samples), so that
+// DeltaT is the time the signal takes from one level to the other.
+func (t *Trace) VoltageCursors(v1, v2 float64) (Cursors, error) {
+
+	c := Cursors{V1: v1, V2: v2}
+	v := t.Volts()
+	x1, ok1 := firstCrossing(v, v1)
+	x2, ok2 := firstCrossing(v, v2)
+	if !ok1 || !ok2 {
+		return c, ErrCursor
+	}
+	c.T1 = (x1 - float64(t.TriggerIndex)) / t.SampleRate
+	c.T2 = (x2 - float64(t.TriggerIndex)) / t.SampleRate
+	return c.deltas(), nil
+}
+
+func (c Cursors) deltas() Cursors {
+	c.DeltaT = c.T2 - c.T1
+	c.Frequency = 1 / math.Abs(c.DeltaT)
+	c.DeltaV = c.V2 - c.V1
+	return c
+}
+
+// firstCrossing returns the position, in samples, at which v first reaches
+// the level.
+func firstCrossing(v []float64, level float64) (float64, bool) {
+	for i := range v {
+		if v[i] == level {
+			return float64(i), true
+		}
+		if i+1 < len(v) && (v[i] < level) != (v[i+1] < level) {
+			return float64(i) + (level-v[i])/(v[i+1]-v[i]), true
+		}
+	}
+	return 0, false
+}
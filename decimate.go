@@ -0,0 +1,107 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"errors"
+)
+
+// Dump modes (register DumpMode)
+const (
+	dumpRaw    = 0
+	dumpBurst  = 1
+	dumpSummed = 2
+	dumpMinMax = 3
+	dumpFilter = 6
+)
+
+// noSkip is the DumpSkip value for a dump without skipped samples.
+const noSkip = 0xffff
+
+// DumpOption selects how the VM reduces the samples of the buffer while
+// dumping them: the dump engine sends a group of samples (each group a
+// number of times), then skips a number of samples, and so on.
+type DumpOption struct {
+	mode   uint
+	repeat uint
+	send   uint
+	skip   uint
+	offset uint
+}
+
+// Raw dumps every sample.
+var Raw = DumpOption{mode: dumpRaw, repeat: 1, send: 1, skip: noSkip}
+
+// Decimate dumps one sample out of n.
+func Decimate(n uint) DumpOption {
+	if n <= 1 {
+		return Raw
+	}
+	return DumpOption{mode: dumpRaw, repeat: 1, send: 1, skip: n - 1}
+}
+
+// Filtered dumps the mean of each n consecutive samples, which lowers the
+// noise as well as the number of samples.
+func Filtered(n uint) DumpOption {
+	if n <= 1 {
+		return Raw
+	}
+	return DumpOption{mode: dumpFilter, repeat: 1, send: 1, skip: n - 1}
+}
+
+// Strided dumps groups of send samples, each repeated repeat times, and
+// skips skip samples after each group. For example, Strided(1, 1, 1) dumps
+// every other sample, and Strided(1, 2, 0) sends each sample twice.
+func Strided(send, repeat, skip uint) DumpOption {
+	if skip == 0 {
+		skip = noSkip
+	}
+	return DumpOption{mode: dumpRaw, repeat: repeat, send: send, skip: skip}
+}
+
+// From returns the option with the dump starting at the given sample of
+// the buffer, instead of the first one. With Strided(1, 1, 1), From(1)
+// extracts the odd samples of an interleaved buffer.
+func (o DumpOption) From(offset uint) DumpOption {
+	o.offset = offset
+	return o
+}
+
+// group returns the number of bytes dumped per group.
+func (o DumpOption) group() uint {
+	if o.repeat == 0 {
+		return o.send
+	}
+	return o.send * o.repeat
+}
+
+// span returns the number of buffer samples covered by a group.
+func (o DumpOption) span() uint {
+	if o.skip == noSkip {
+		return o.send
+	}
+	return o.send + o.skip
+}
+
+// address returns the buffer address, relative to the start of the dump,
+// of the group that starts at byte n of the dump. n must be a multiple of
+// the group size.
+func (o DumpOption) address(n uint) uint {
+	return n / o.group() * o.span()
+}
+
+// DumpWith reads size samples of the data buffer, reduced as selected by
+// opt: with Decimate(10), the samples cover 10 times size samples of the
+// buffer, which is transferred in a tenth of the time.
+func (bs *Scope) DumpWith(size uint, opt DumpOption) ([]byte, error) {
+
+	if opt.send == 0 || opt.repeat == 0 {
+		return nil, errors.New("Invalid dump option")
+	}
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.dumpWith(context.Background(), dumpBase+opt.offset, size, opt)
+}
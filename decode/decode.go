@@ -0,0 +1,49 @@
+// For the license see the LICENSE file (BSD style)
+
+// Package decode decodes serial protocols from the samples of the logic
+// inputs, or from analog traces sliced into logic levels:
+//
+//	l, err := bs.LogicDump(4096)
+//	frames, err := decode.UART(l.Channels[0], l.Rate, decode.UARTConfig{Baud: 9600})
+//
+// Times are in seconds from the first sample.
+package decode
+
+import (
+	"errors"
+
+	"bitscope"
+)
+
+// ErrRate is returned when the sample rate is too low for the signal.
+var ErrRate = errors.New("Sample rate too low to decode")
+
+// Slice converts an analog trace to logic levels: a sample is true above
+// hi, false below lo, and keeps the level of the previous sample in
+// between (hysteresis). Samples before the first that leaves the band take
+// its level. The levels are in volts (see Trace.Volts).
+func Slice(t *bitscope.Trace, lo, hi float64) []bool {
+
+	v := t.Volts()
+	x := make([]bool, len(v))
+
+	// The first level out of the band
+	state := false
+	for _, s := range v {
+		if s > hi || s < lo {
+			state = s > hi
+			break
+		}
+	}
+
+	for i, s := range v {
+		switch {
+		case s > hi:
+			state = true
+		case s < lo:
+			state = false
+		}
+		x[i] = state
+	}
+	return x
+}
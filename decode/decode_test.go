@@ -0,0 +1,105 @@
+// For the license see the LICENSE file (BSD style)
+
+package decode_test
+
+import (
+	"fmt"
+
+	"bitscope"
+	"bitscope/decode"
+)
+
+// serial returns the samples of a line at the given levels, each bit
+// lasting n samples.
+func serial(n int, bits string) []bool {
+	var x []bool
+	for _, b := range bits {
+		for i := 0; i < n; i++ {
+			x = append(x, b == '1')
+		}
+	}
+	return x
+}
+
+func ExampleSlice() {
+	t := &bitscope.Trace{Values: []float64{1.5, 0.2, 1.5, 3.1, 1.5, 2, 0.1, 1.5}}
+	fmt.Println(decode.Slice(t, 0.8, 2.5))
+	// Output: [false false false true true true false false]
+}
+
+func ExampleUART() {
+	// 'H' and 'i' (8E1) at 10 samples per bit, the second with a wrong
+	// parity bit and a missing stop bit
+	x := serial(10, "111"+"0000100100"+"11"+"0100101101"+"0"+"1111")
+
+	frames, err := decode.UART(x, 96000, decode.UARTConfig{Baud: 9600, Parity: decode.Even})
+	for _, f := range frames {
+		fmt.Printf("%.1fus %q %v %v\n", f.Time*1e6, rune(f.Data), f.ParityError, f.FramingError)
+	}
+	fmt.Println(err)
+	// Output:
+	// 312.5us 'H' false false
+	// 1562.5us 'i' true true
+	// <nil>
+}
+
+// i2c returns the samples of SDA and SCL of a sequence of bus states: 's'
+// for a start, 'p' for a stop, '0' and '1' for a bit (four samples), and
+// '_' for SCL held low by a slave (clock stretching).
+func i2c(seq string) (sda, scl []bool) {
+	add := func(d, c bool) {
+		sda, scl = append(sda, d), append(scl, c)
+	}
+	add(true, true)
+	for _, s := range seq {
+		d := sda[len(sda)-1]
+		switch s {
+		case 's':
+			add(true, scl[len(scl)-1])
+			add(true, true)
+			add(false, true)
+			add(false, false)
+		case 'p':
+			add(false, false)
+			add(false, true)
+			add(true, true)
+		case '0', '1':
+			d = s == '1'
+			add(d, false)
+			add(d, true)
+			add(d, true)
+			add(d, false)
+		case '_':
+			add(d, false)
+		}
+	}
+	return sda, scl
+}
+
+func ExampleI2C() {
+	// Select register 0x10 of device 0x50 and read it (0xa5), with the
+	// device stretching the clock before the data
+	sda, scl := i2c("s" + "10100000" + "0" + "00010000" + "0" + "s" + "10100001" + "0" + "____" + "10100101" + "1" + "p")
+
+	ev, err := decode.I2C(sda, scl, 1e6)
+	for _, e := range ev {
+		switch e.Kind {
+		case decode.I2CAddress:
+			fmt.Printf("%s %#x read=%v ack=%v\n", e.Kind, e.Value, e.Read, e.Ack)
+		case decode.I2CData:
+			fmt.Printf("%s %#x ack=%v\n", e.Kind, e.Value, e.Ack)
+		default:
+			fmt.Printf("%s %.0fus\n", e.Kind, e.Time*1e6)
+		}
+	}
+	fmt.Println(err)
+	// Output:
+	// start 3us
+	// address 0x50 read=false ack=true
+	// data 0x10 ack=true
+	// restart 79us
+	// address 0x50 read=true ack=true
+	// data 0xa5 ack=false
+	// stop 159us
+	// <nil>
+}
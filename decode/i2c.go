@@ -0,0 +1,113 @@
+// For the license see the LICENSE file (BSD style)
+
+package decode
+
+import "errors"
+
+// I2CKind is the kind of an I2CEvent.
+type I2CKind int
+
+const (
+	// Start condition: SDA falls while SCL is high
+	I2CStart I2CKind = iota
+	// Start condition without a stop since the previous one
+	I2CRestart
+	// Stop condition: SDA rises while SCL is high
+	I2CStop
+	// First byte after a start: a 7 bit address and the R/W bit
+	I2CAddress
+	// Any other byte
+	I2CData
+)
+
+func (k I2CKind) String() string {
+	switch k {
+	case I2CStart:
+		return "start"
+	case I2CRestart:
+		return "restart"
+	case I2CStop:
+		return "stop"
+	case I2CAddress:
+		return "address"
+	case I2CData:
+		return "data"
+	}
+	return "unknown"
+}
+
+// I2CEvent is a condition or a byte decoded by I2C.
+type I2CEvent struct {
+	// Time of the condition, or of the first clock of the byte
+	Time float64
+	Kind I2CKind
+	// Data byte, or address without the R/W bit
+	Value byte
+	// R/W bit of an address (true: read)
+	Read bool
+	// Acknowledge bit of a byte (true: ACK, SDA low)
+	Ack bool
+}
+
+// I2C decodes an I2C bus from the samples of SDA and SCL taken at rate. The
+// bits are read at the rising edges of SCL, so the decoding does not
+// depend on the clock rate and tolerates clock stretching, as long as each
+// clock level lasts at least one sample. Bytes cut short by a start or stop
+// condition are dropped.
+func I2C(sda, scl []bool, rate float64) ([]I2CEvent, error) {
+
+	if len(sda) != len(scl) {
+		return nil, errors.New("SDA and SCL of different lengths")
+	}
+
+	var ev []I2CEvent
+	inside := false
+	// Bits of the current byte, the first of them at sample first, and
+	// whether it is the first byte after a start
+	bits, n, first, addr := 0, -1, 0, false
+
+	for i := 1; i < len(sda); i++ {
+
+		t := float64(i) / rate
+
+		switch {
+		case scl[i-1] && scl[i] && sda[i-1] && !sda[i]:
+			k := I2CStart
+			if inside {
+				k = I2CRestart
+			}
+			ev = append(ev, I2CEvent{Time: t, Kind: k})
+			inside, n, addr = true, 0, true
+
+		case scl[i-1] && scl[i] && !sda[i-1] && sda[i]:
+			ev = append(ev, I2CEvent{Time: t, Kind: I2CStop})
+			inside, n = false, -1
+
+		case !scl[i-1] && scl[i] && n >= 0:
+			if n == 0 {
+				bits, first = 0, i
+			}
+			if n < 8 {
+				bits = bits<<1 | b2i(sda[i])
+				n++
+				continue
+			}
+
+			// Acknowledge bit
+			e := I2CEvent{Time: float64(first) / rate, Kind: I2CData, Value: byte(bits), Ack: !sda[i]}
+			if addr {
+				e.Kind, e.Value, e.Read = I2CAddress, byte(bits>>1), bits&1 != 0
+			}
+			ev = append(ev, e)
+			n, addr = 0, false
+		}
+	}
+	return ev, nil
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
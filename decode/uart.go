@@ -0,0 +1,121 @@
+// For the license see the LICENSE file (BSD style)
+
+package decode
+
+import "errors"
+
+// Parity is the parity bit of a UART frame.
+type Parity int
+
+const (
+	NoParity Parity = iota
+	Even
+	Odd
+)
+
+func (p Parity) String() string {
+	switch p {
+	case NoParity:
+		return "none"
+	case Even:
+		return "even"
+	case Odd:
+		return "odd"
+	}
+	return "unknown"
+}
+
+// UARTConfig is the format of a UART line.
+type UARTConfig struct {
+	// Bits per second
+	Baud float64
+	// Data bits (default 8) and stop bits (default 1)
+	Bits, StopBits int
+	Parity         Parity
+	// Inverted line, idle low (as RS-232 levels before the transceiver)
+	Invert bool
+}
+
+// Frame is a character decoded by UART.
+type Frame struct {
+	// Time of the start of the start bit
+	Time float64
+	Data uint16
+	// Wrong parity bit, or missing stop bit
+	ParityError, FramingError bool
+}
+
+// UART decodes the frames of an asynchronous serial line sampled at rate.
+// Each frame starts at a transition from idle to the start bit, and its
+// bits are sampled at their middle, least significant first. A start bit
+// that does not last until its middle is taken as a glitch. The sample
+// rate must be at least 4 times the baud rate.
+func UART(x []bool, rate float64, cfg UARTConfig) ([]Frame, error) {
+
+	if cfg.Bits == 0 {
+		cfg.Bits = 8
+	}
+	if cfg.StopBits == 0 {
+		cfg.StopBits = 1
+	}
+	if cfg.Baud <= 0 || cfg.Bits > 16 || cfg.StopBits < 0 {
+		return nil, errors.New("Invalid UART configuration")
+	}
+	bit := rate / cfg.Baud
+	if bit < 4 {
+		return nil, ErrRate
+	}
+
+	// Level of the line, idle high
+	level := func(i float64) bool { return x[int(i)] != cfg.Invert }
+
+	n := cfg.Bits + cfg.StopBits
+	if cfg.Parity != NoParity {
+		n++
+	}
+
+	var frames []Frame
+	for i := 1; i < len(x); i++ {
+
+		if !level(float64(i-1)) || level(float64(i)) {
+			continue
+		}
+		start := float64(i)
+		// The last bit must be in the trace
+		if int(start+(float64(n)+0.5)*bit) >= len(x) {
+			break
+		}
+		if level(start + 0.5*bit) {
+			continue
+		}
+
+		f := Frame{Time: start / rate}
+		at := start + 1.5*bit
+		ones := 0
+		for k := 0; k < cfg.Bits; k++ {
+			if level(at) {
+				f.Data |= 1 << uint(k)
+				ones++
+			}
+			at += bit
+		}
+		if cfg.Parity != NoParity {
+			if level(at) {
+				ones++
+			}
+			f.ParityError = (ones%2 == 1) == (cfg.Parity == Even)
+			at += bit
+		}
+		for k := 0; k < cfg.StopBits; k++ {
+			if !level(at) {
+				f.FramingError = true
+			}
+			at += bit
+		}
+		frames = append(frames, f)
+
+		// Look for the next start bit from the middle of the last stop bit
+		i = int(at - bit)
+	}
+	return frames, nil
+}
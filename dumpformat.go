@@ -0,0 +1,71 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"strconv"
+)
+
+// DumpFormat selects how the VM transfers the samples of a dump.
+type DumpFormat int
+
+const (
+	// Binary, one byte per sample ('A'). The fastest format.
+	DumpBinary DumpFormat = iota
+	// Text, one sample per line as a hex number ('S'). Three times slower,
+	// but a lost or altered byte is detected instead of shifting the data.
+	DumpHex
+)
+
+// Sample is the ADC code (or logic state) of a sample in the capture
+// buffer, whatever the format in which it was transferred.
+type Sample uint16
+
+// SetDumpFormat selects the transfer format used by Dump. The data returned
+// by Dump is the same in all formats.
+func (bs *Scope) SetDumpFormat(f DumpFormat) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	bs.format = f
+}
+
+// DumpSamples is Dump, returning the samples as a []Sample.
+func (bs *Scope) DumpSamples(size uint) ([]Sample, error) {
+
+	b, err := bs.Dump(size)
+
+	s := make([]Sample, len(b))
+	for i, c := range b {
+		s[i] = Sample(c)
+	}
+	return s, err
+}
+
+// dumpChunk dumps n bytes, as set up by dumpSetup, in the selected format.
+func (bs *Scope) dumpChunk(ctx context.Context, n uint) ([]byte, error) {
+
+	if bs.format != DumpHex {
+		return bs.callData(ctx, []byte("A"), n)
+	}
+
+	// 3 bytes per sample
+	t := bs.transferTimeout(3 * int(n))
+
+	r, err := bs.exchangeRetry(ctx, []byte("S"), frame{lines: int(n)}, t)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 0, n)
+	for _, l := range r.lines {
+		v, err := strconv.ParseUint(l, 16, 8)
+		if err != nil {
+			return b, ErrCorruptDump
+		}
+		b = append(b, byte(v))
+	}
+	return b, nil
+}
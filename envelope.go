@@ -0,0 +1,45 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import "errors"
+
+// Envelope captures n triggered traces (see TraceRepeat) and returns the
+// minimum and maximum of the samples in each bucket of the given number of
+// consecutive samples, over all traces (peak detection). With a bucket of 1
+// it is the envelope of the traces; with larger buckets a long trace is
+// reduced in resolution without losing short glitches.
+func (bs *Scope) Envelope(cfg TraceConfig, n, bucket int) (min, max []byte, err error) {
+
+	if n < 1 || bucket < 1 {
+		return nil, nil, errors.New("Envelope needs at least one trace and one sample per bucket")
+	}
+
+	err = bs.TraceRepeat(cfg, n, func(data []byte) bool {
+
+		if min == nil {
+			k := (len(data) + bucket - 1) / bucket
+			min = make([]byte, k)
+			max = make([]byte, k)
+			for i := range min {
+				min[i] = 0xff
+			}
+		}
+
+		for i, c := range data {
+			j := i / bucket
+			if j >= len(min) {
+				break
+			}
+			if c < min[j] {
+				min[j] = c
+			}
+			if c > max[j] {
+				max[j] = c
+			}
+		}
+		return true
+	})
+
+	return min, max, err
+}
@@ -0,0 +1,60 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"errors"
+)
+
+// frequencyGate is the number of sample clocks during which edges are
+// counted by MeasureFrequency.
+const frequencyGate = 0xffff
+
+// MeasureFrequency measures the frequency of the signal of analog channel
+// ch ('a' or 'b') in hardware, in Hz: the VM counts the rising edges at the
+// output of the channel comparator (see Trigger for its level) during
+// 65535 sample clocks. The resolution is therefore the sample rate divided
+// by 65535, and the sample rate must be at least twice the frequency.
+func (bs *Scope) MeasureFrequency(ch uint) (float64, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	i, err := channel(ch)
+	if err != nil {
+		return 0, err
+	}
+
+	rate := bs.sampleRate()
+	if rate == 0 {
+		return 0, errors.New("Sample rate not set")
+	}
+
+	ctx := context.Background()
+
+	if err := bs.traceSetup(captureFreq, 0, frequencyGate, 0); err != nil {
+		return 0, err
+	}
+
+	// Count at the comparator of the channel
+	err = bs.pipeline(ctx,
+		reg(RegSpockOption, 0x21|uint(i)<<2),
+		reg(RegKitchenSinkA, 0x80>>uint(i)),
+		[]byte(">"),
+		[]byte("U"))
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := bs.traceStart(ctx); err != nil {
+		return 0, err
+	}
+
+	n, err := bs.readReg(ctx, RegSampleCounter)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(n) * rate / frequencyGate, nil
+}
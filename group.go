@@ -0,0 +1,136 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"sync"
+)
+
+// Group is a set of BitScopes that are configured together and whose traces
+// are started as close to simultaneously as possible, e.g. several BS05
+// units forming a multi-channel instrument.
+type Group struct {
+	Scopes []*Scope
+}
+
+// Result holds the response of one member of a Group, in the same order as
+// Group.Scopes.
+type Result struct {
+	Data []byte
+	Err  error
+}
+
+// NewGroup returns a Group with the given scopes.
+func NewGroup(scopes ...*Scope) *Group {
+	return &Group{scopes}
+}
+
+// Close closes all the scopes of the group, returning the first error.
+func (g *Group) Close() error {
+	var err error
+	for _, bs := range g.Scopes {
+		if e := bs.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Vertical sets the voltage range of all scopes, returning the first error.
+func (g *Group) Vertical(rng string) error {
+	for _, bs := range g.Scopes {
+		if err := bs.Vertical(rng); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Horizontal sets the time base of all scopes, returning the first error.
+func (g *Group) Horizontal(pre, div uint) error {
+	for _, bs := range g.Scopes {
+		if err := bs.Horizontal(pre, div); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Trigger sets the analog trigger of all scopes, returning the first error.
+func (g *Group) Trigger(src, level uint) error {
+	for _, bs := range g.Scopes {
+		if err := bs.Trigger(src, level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TriggerTiming sets the trigger timing of all scopes, returning the first
+// error.
+func (g *Group) TriggerTiming(hoff, hon, timeout uint) error {
+	for _, bs := range g.Scopes {
+		if err := bs.TriggerTiming(hoff, hon, timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Trace sets up a trace on all scopes in parallel and, once all of them are
+// ready, starts them at the same time. It waits until all traces have
+// completed.
+func (g *Group) Trace(pre, post, delay uint) []Result {
+
+	res := make([]Result, len(g.Scopes))
+	start := make(chan struct{})
+
+	var ready, done sync.WaitGroup
+	ready.Add(len(g.Scopes))
+	done.Add(len(g.Scopes))
+
+	for i, bs := range g.Scopes {
+		go func(i int, bs *Scope) {
+			defer done.Done()
+
+			bs.mu.Lock()
+			defer bs.mu.Unlock()
+
+			err := bs.traceSetup(captureAnalog, pre, post, delay)
+			ready.Done()
+			<-start
+			if err != nil {
+				res[i].Err = err
+				return
+			}
+			res[i].Data, res[i].Err = bs.traceStart(context.Background())
+		}(i, bs)
+	}
+
+	ready.Wait()
+	close(start)
+	done.Wait()
+
+	return res
+}
+
+// Dump reads the data buffers of all scopes in parallel.
+func (g *Group) Dump(size uint) []Result {
+
+	res := make([]Result, len(g.Scopes))
+
+	var done sync.WaitGroup
+	done.Add(len(g.Scopes))
+
+	for i, bs := range g.Scopes {
+		go func(i int, bs *Scope) {
+			defer done.Done()
+			res[i].Data, res[i].Err = bs.Dump(size)
+		}(i, bs)
+	}
+
+	done.Wait()
+
+	return res
+}
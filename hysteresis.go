@@ -0,0 +1,91 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import "errors"
+
+// HysteresisAuto makes TriggerHysteresis choose a value from the vertical
+// range and the model.
+const HysteresisAuto = -1
+
+// TriggerHysteresis sets the hysteresis of the analog trigger in volts at
+// the probe tip, so that noise around the trigger level does not trigger
+// twice. With hysteresis the comparator uses two thresholds, at the trigger
+// level plus and minus half of it, and swaps between them (SpockOption bit
+// 1), as the window trigger does. A value of 0 disables it, HysteresisAuto
+// selects a few ADC codes of the current vertical range, more on the noisier
+// BS05.
+//
+// The vertical range must be set, and TriggerHysteresis must be called again
+// when it changes. TriggerWindow disables the hysteresis.
+func (bs *Scope) TriggerHysteresis(volts float64) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if bs.vrange == 0 {
+		return errors.New("Vertical range not set")
+	}
+
+	switch {
+	case volts == HysteresisAuto:
+		codes := 2.0
+		if bs.Model == "bs05" {
+			codes = 3
+		}
+		volts = codes * bs.vrange / 255
+	case volts < 0:
+		return errors.New("Negative trigger hysteresis")
+	default:
+		i, _ := channel(bs.trigSrc)
+		volts /= bs.probe(i)
+	}
+
+	// In TriggerLevel units: the ADC code in the high byte
+	h := uint(volts / bs.vrange * 255 * 256)
+	if h > 0xffff {
+		return errors.New("Trigger hysteresis larger than the vertical range")
+	}
+	return bs.hysteresis(h)
+}
+
+// hysteresis sets the hysteresis of the analog trigger in TriggerLevel
+// units and programs the trigger level and mode with it.
+func (bs *Scope) hysteresis(h uint) error {
+
+	old := bs.hyst
+	bs.hyst = h
+	if _, err := bs.set("trigger", bs.triggerLevels()); err != nil {
+		bs.hyst = old
+		return err
+	}
+	return bs.triggerMode(bs.spock)
+}
+
+// triggerLevels returns the commands that program the analog trigger level,
+// as a pair of thresholds if there is hysteresis.
+func (bs *Scope) triggerLevels() []byte {
+
+	if bs.hyst == 0 {
+		return reg(RegTriggerLevel, bs.trigLevel)
+	}
+
+	h := bs.hyst / 2
+	lo, hi := uint(0), bs.trigLevel+h
+	if bs.trigLevel > h {
+		lo = bs.trigLevel - h
+	}
+	if hi > 0xffff {
+		hi = 0xffff
+	}
+	return append(reg(RegTriggerLevel, lo), reg(RegTriggerUpper, hi)...)
+}
+
+// spockOption returns the SpockOption register value: the trigger mode,
+// with the swap bit set if there is hysteresis.
+func (bs *Scope) spockOption() uint {
+	if bs.hyst != 0 {
+		return bs.spock | 2
+	}
+	return bs.spock
+}
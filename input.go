@@ -0,0 +1,116 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import "errors"
+
+// Source is the connector from which an analog channel is sampled.
+type Source int
+
+const (
+	// The BNC connector of the channel (default)
+	BNC Source = iota
+	// The analog input of the channel on the POD header (BS10)
+	POD
+)
+
+// InputSource selects the connector of analog channel ch ('a' or 'b') for
+// the following traces.
+func (bs *Scope) InputSource(ch uint, src Source) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	i, err := channel(ch)
+	if err != nil {
+		return err
+	}
+	if src != BNC && src != POD {
+		return errors.New("Unknown input source")
+	}
+	if src == POD && bs.Model != "bs10" {
+		return errors.New("POD analog inputs are only available on the BS10")
+	}
+
+	bs.source[i] = src
+	return nil
+}
+
+// SoftwareAC makes the conversion to volts of the traces of analog channel
+// ch ('a' or 'b') remove their mean, as AC coupling would. This is not
+// input coupling: the inputs of the BS05 and BS10 are DC coupled, with no
+// register to change that, so the ADC codes still have the DC component,
+// and the signal must be within the vertical range.
+func (bs *Scope) SoftwareAC(ch uint, on bool) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	i, err := channel(ch)
+	if err != nil {
+		return err
+	}
+
+	bs.softAC[i] = on
+	return nil
+}
+
+// Attenuation sets the attenuation factor of the probe connected to
+// analog channel ch ('a' or 'b'): 1 for a x1 probe (default), 10 for a x10
+// probe. Voltages are scaled by it, so that they are those at the probe
+// tip.
+func (bs *Scope) Attenuation(ch uint, factor float64) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	i, err := channel(ch)
+	if err != nil {
+		return err
+	}
+	if factor <= 0 {
+		return errors.New("Invalid attenuation factor")
+	}
+
+	bs.attenuation[i] = factor
+	return nil
+}
+
+// probe returns the attenuation factor of channel i.
+func (bs *Scope) probe(i int) float64 {
+	if bs.attenuation[i] == 0 {
+		return 1
+	}
+	return bs.attenuation[i]
+}
+
+// channel returns the index of an analog channel ('a' or 'b').
+func channel(ch uint) (int, error) {
+	switch ch {
+	case 'a', 'A':
+		return 0, nil
+	case 'b', 'B':
+		return 1, nil
+	}
+	return 0, errors.New("Unknown channel")
+}
+
+// analogEnable returns the value of the AnalogEnable register for the
+// channels enabled in a capture (bit 0: CHA, bit 1: CHB). The inputs of
+// the POD header are enabled by bits 2 and 3 instead, for CHA also while
+// it is the external trigger source.
+func (bs *Scope) analogEnable(c capture) uint {
+
+	var v uint
+	for i := uint(0); i < 2; i++ {
+		if c.analog&(1<<i) == 0 {
+			continue
+		}
+		if bs.source[i] == POD || (i == 0 && bs.trigSource == TrigExternal) {
+			v |= 4 << i
+		} else {
+			v |= 1 << i
+		}
+	}
+	return v
+}
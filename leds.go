@@ -0,0 +1,109 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Blink flashes LED n ('r', 'g' or 'y', as in Led) in a goroutine until ctx
+// is done, and then turns it off. The pattern gives the state of the LED
+// ('1' on, '0' off) in consecutive intervals of length step, and is
+// repeated: "10" blinks and "1000" flashes briefly.
+func (bs *Scope) Blink(ctx context.Context, n uint, pattern string, step time.Duration) error {
+
+	if pattern == "" || step <= 0 {
+		return errors.New("Invalid blink pattern")
+	}
+	for _, c := range pattern {
+		if c != '0' && c != '1' {
+			return errors.New("Invalid blink pattern")
+		}
+	}
+
+	go func() {
+		t := time.NewTicker(step)
+		defer t.Stop()
+
+		for i := 0; ; i++ {
+			var v uint
+			if pattern[i%len(pattern)] == '1' {
+				v = 0xff
+			}
+			if bs.Led(n, v) != nil {
+				return
+			}
+
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				bs.Led(n, 0)
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Heartbeat makes LED n give a short flash every second until ctx is done,
+// to show that a program using the BitScope is alive.
+func (bs *Scope) Heartbeat(ctx context.Context, n uint) error {
+	return bs.Blink(ctx, n, "1000000000", 100*time.Millisecond)
+}
+
+// StatusLeds makes the LEDs of the BS10 show the state of the acquisitions:
+// yellow while a trace is armed, green when it has been triggered and red
+// when it failed. An untriggered trace turns them off.
+func (bs *Scope) StatusLeds(on bool) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	bs.leds = on
+}
+
+// LED states of StatusLeds
+const (
+	ledsOff = iota
+	ledsArmed
+	ledsTriggered
+	ledsError
+)
+
+// showStatus sets the LEDs to a state, if StatusLeds is on. Errors are
+// ignored, since the LEDs are only an indication.
+func (bs *Scope) showStatus(s int) {
+
+	if !bs.leds {
+		return
+	}
+
+	var r, g, y uint
+	switch s {
+	case ledsArmed:
+		y = 0xff
+	case ledsTriggered:
+		g = 0xff
+	case ledsError:
+		r = 0xff
+	}
+
+	b := append(reg(RegLedRed, r), reg(RegLedGreen, g)...)
+	bs.call(append(b, reg(RegLedYellow, y)...))
+}
+
+// showTraceStatus sets the LEDs at the end of a trace with response r to
+// 'D', or error err.
+func (bs *Scope) showTraceStatus(r []byte, err error) {
+	switch {
+	case err != nil:
+		bs.showStatus(ledsError)
+	case bs.triggerCause(r) == CauseTrigger:
+		bs.showStatus(ledsTriggered)
+	default:
+		bs.showStatus(ledsOff)
+	}
+}
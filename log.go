@@ -0,0 +1,34 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+// Logger is used to trace the traffic with the BitScope. A *log.Logger can
+// be used.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// LogLevel selects what is logged.
+type LogLevel int
+
+const (
+	// Nothing is logged (the default)
+	LogNone LogLevel = iota
+	// The commands sent to the BitScope
+	LogCommands
+	// The commands and the raw responses
+	LogRaw
+)
+
+// SetLogger sets the logger and the log level of the Scope. A nil logger
+// disables logging.
+func (bs *Scope) SetLogger(l Logger, level LogLevel) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if l == nil {
+		level = LogNone
+	}
+	bs.logger = l
+	bs.logLevel = level
+}
@@ -0,0 +1,57 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import "context"
+
+// LogicCapture holds the samples of the 8 logic inputs.
+type LogicCapture struct {
+	// Sample rate in samples per second, as set with Horizontal (0 if
+	// unknown)
+	Rate float64
+	// One byte per sample; bit n is the state of logic input n
+	Raw []byte
+	// The samples of each logic input
+	Channels [8][]bool
+}
+
+// LogicTrace is Trace for the 8 logic inputs: it enables them and captures
+// their state at each sample, instead of the analog channels.
+func (bs *Scope) LogicTrace(pre, post, delay uint) ([]byte, error) {
+	return bs.LogicTraceContext(context.Background(), pre, post, delay)
+}
+
+// LogicTraceContext is LogicTrace with a context (see TraceContext).
+func (bs *Scope) LogicTraceContext(ctx context.Context, pre, post, delay uint) ([]byte, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.traceCapture(ctx, captureLogic, pre, post, delay)
+}
+
+// LogicDump reads size samples after a LogicTrace.
+func (bs *Scope) LogicDump(size uint) (*LogicCapture, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	d, err := bs.dump(context.Background(), size)
+
+	return newLogicCapture(d, bs.sampleRate()), err
+}
+
+// newLogicCapture splits logic samples into channels.
+func newLogicCapture(d []byte, rate float64) *LogicCapture {
+
+	l := &LogicCapture{Rate: rate, Raw: d}
+
+	for n := range l.Channels {
+		ch := make([]bool, len(d))
+		for i, c := range d {
+			ch[i] = c&(1<<uint(n)) != 0
+		}
+		l.Channels[n] = ch
+	}
+	return l
+}
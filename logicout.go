@@ -0,0 +1,25 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import "errors"
+
+// LogicWrite drives the logic lines of the POD of the BS10: the lines in
+// mask become outputs, set to the levels of the corresponding bits of
+// value, and the other lines are inputs. LogicWrite(0, 0) releases all the
+// lines. The outputs keep their levels during traces, so that they can be
+// captured with the inputs in logic or mixed traces.
+func (bs *Scope) LogicWrite(mask, value byte) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if bs.Model != "bs10" {
+		return errors.New("Logic outputs are only available on the BS10")
+	}
+
+	// Set the levels before enabling the outputs, to avoid glitches
+	b := append(reg(RegLogicOutput, uint(value&mask)), reg(RegLogicControl, uint(mask))...)
+	_, err := bs.set("logicout", append(b, '>'))
+	return err
+}
@@ -0,0 +1,57 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import "context"
+
+// TraceMacro is Trace of CHA in macro mode: two converters sample the
+// channel alternately, doubling the sample rate set with Horizontal. Each
+// converter stores its samples in one half of the buffer, so at most half
+// the buffer is available for pre and post samples each; DumpMacro puts
+// them back in order.
+func (bs *Scope) TraceMacro(pre, post, delay uint) ([]byte, error) {
+	return bs.TraceMacroContext(context.Background(), pre, post, delay)
+}
+
+// TraceMacroContext is TraceMacro with a context (see TraceContext).
+func (bs *Scope) TraceMacroContext(ctx context.Context, pre, post, delay uint) ([]byte, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	// Samples per converter
+	return bs.traceCapture(ctx, captureMacro, (pre+1)/2, (post+1)/2, delay)
+}
+
+// DumpMacro reads size samples after a TraceMacro, in the order in which
+// they were taken.
+func (bs *Scope) DumpMacro(size uint) ([]byte, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	ctx := context.Background()
+	n := (size + 1) / 2
+
+	even, err := bs.dumpAt(ctx, dumpBase, n)
+	if err != nil {
+		return nil, err
+	}
+	odd, err := bs.dumpAt(ctx, dumpBase+bufferSize/2, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return interleave(even, odd)[:size], nil
+}
+
+// interleave merges two halves of a macro buffer, taking samples alternately
+// from a and b, which have the same length.
+func interleave(a, b []byte) []byte {
+
+	d := make([]byte, 0, len(a)+len(b))
+	for i := range a {
+		d = append(d, a[i], b[i])
+	}
+	return d
+}
@@ -0,0 +1,107 @@
+// For the license see the LICENSE file (BSD style)
+
+package math
+
+import (
+	"errors"
+	"math"
+
+	"bitscope"
+)
+
+// ErrFrequency is returned for a filter frequency that is not between 0
+// and half the sample rate of the trace.
+var ErrFrequency = errors.New("Filter frequency out of range")
+
+// filter returns a trace with the values of t passed through f, which gets
+// the sample rate.
+func filter(t *bitscope.Trace, hz float64, f func(v []float64, rate float64) []float64) (*bitscope.Trace, error) {
+
+	if hz <= 0 || hz >= t.SampleRate/2 {
+		return nil, ErrFrequency
+	}
+	d, err := Scale(t, 1, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(d.Values) == 0 {
+		return nil, errors.New("Empty trace")
+	}
+	d.Values = f(d.Values, t.SampleRate)
+	return d, nil
+}
+
+// MovingAverage returns t averaged over one period of hz (rounded to
+// whole samples), which removes that frequency and its harmonics: 50 or
+// 60 Hz removes mains hum, for example. Each value is the average of the
+// samples up to it.
+func MovingAverage(t *bitscope.Trace, hz float64) (*bitscope.Trace, error) {
+	return filter(t, hz, func(v []float64, rate float64) []float64 {
+		n := int(math.Max(1, math.Round(rate/hz)))
+		y := make([]float64, len(v))
+		sum := 0.0
+		for i, x := range v {
+			sum += x
+			k := i + 1
+			if i >= n {
+				sum -= v[i-n]
+				k = n
+			}
+			y[i] = sum / float64(k)
+		}
+		return y
+	})
+}
+
+// LowPass returns t through a single pole low pass filter with a cut-off
+// frequency of hz (-3 dB).
+func LowPass(t *bitscope.Trace, hz float64) (*bitscope.Trace, error) {
+	return filter(t, hz, func(v []float64, rate float64) []float64 {
+		dt, rc := 1/rate, 1/(2*math.Pi*hz)
+		a := dt / (rc + dt)
+		y := make([]float64, len(v))
+		y[0] = v[0]
+		for i := 1; i < len(v); i++ {
+			y[i] = y[i-1] + a*(v[i]-y[i-1])
+		}
+		return y
+	})
+}
+
+// HighPass returns t through a single pole high pass filter with a cut-off
+// frequency of hz (-3 dB). It removes the DC level.
+func HighPass(t *bitscope.Trace, hz float64) (*bitscope.Trace, error) {
+	return filter(t, hz, func(v []float64, rate float64) []float64 {
+		dt, rc := 1/rate, 1/(2*math.Pi*hz)
+		a := rc / (rc + dt)
+		y := make([]float64, len(v))
+		for i := 1; i < len(v); i++ {
+			y[i] = a * (y[i-1] + v[i] - v[i-1])
+		}
+		return y
+	})
+}
+
+// Notch returns t through a second order notch filter at hz, with quality
+// factor q: the width of the notch (at -3 dB) is hz/q.
+func Notch(t *bitscope.Trace, hz, q float64) (*bitscope.Trace, error) {
+	if q <= 0 {
+		return nil, errors.New("Invalid filter quality factor")
+	}
+	return filter(t, hz, func(v []float64, rate float64) []float64 {
+		w := 2 * math.Pi * hz / rate
+		alpha := math.Sin(w) / (2 * q)
+		c := -2 * math.Cos(w)
+		a0 := 1 + alpha
+
+		// Start at rest at the first value, to avoid a transient
+		y := make([]float64, len(v))
+		x1, x2, y1, y2 := v[0], v[0], v[0], v[0]
+		for i, x := range v {
+			y[i] = (x + c*x1 + x2 - c*y1 - (1-alpha)*y2) / a0
+			x2, x1 = x1, x
+			y2, y1 = y1, y[i]
+		}
+		return y
+	})
+}
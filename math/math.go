@@ -0,0 +1,110 @@
+// For the license see the LICENSE file (BSD style)
+
+// Package math derives traces from others, as the math channels of an
+// oscilloscope: for example the difference of two channels, to measure a
+// differential signal with two probes:
+//
+//	d, err := math.Sub(a, b)
+//
+// The derived traces hold their values in Trace.Values, with their unit in
+// Trace.Unit, and can be measured with package measure. The timing
+// metadata is taken from the first trace.
+package math
+
+import (
+	"errors"
+
+	"bitscope"
+)
+
+// ErrMismatch is returned for traces with a different number of samples
+// or sample rate.
+var ErrMismatch = errors.New("Traces with different sampling")
+
+// ErrUnit is returned when adding or subtracting traces with different
+// units.
+var ErrUnit = errors.New("Traces with different units")
+
+// unit returns the unit of the values of a trace.
+func unit(t *bitscope.Trace) string {
+	if t.Unit == "" {
+		return "V"
+	}
+	return t.Unit
+}
+
+// derive returns a trace with the metadata of t, and the values f(x)
+// computed from the values x of the traces.
+func derive(u string, f func(x []float64) float64, ts ...*bitscope.Trace) (*bitscope.Trace, error) {
+
+	t := ts[0]
+	v := make([][]float64, len(ts))
+	for i, s := range ts {
+		if s.SampleRate != t.SampleRate || len(s.Volts()) != len(t.Volts()) {
+			return nil, ErrMismatch
+		}
+		v[i] = s.Volts()
+		if v[i] == nil {
+			return nil, errors.New("Trace cannot be converted to volts")
+		}
+	}
+
+	d := &bitscope.Trace{
+		SampleRate:   t.SampleRate,
+		TriggerIndex: t.TriggerIndex,
+		Timestamp:    t.Timestamp,
+		Cause:        t.Cause,
+		Channel:      t.Channel,
+		Values:       make([]float64, len(v[0])),
+		Unit:         u,
+	}
+
+	x := make([]float64, len(ts))
+	for i := range d.Values {
+		for j := range v {
+			x[j] = v[j][i]
+		}
+		d.Values[i] = f(x)
+	}
+	return d, nil
+}
+
+// Add returns a + b.
+func Add(a, b *bitscope.Trace) (*bitscope.Trace, error) {
+	if unit(a) != unit(b) {
+		return nil, ErrUnit
+	}
+	return derive(unit(a), func(x []float64) float64 { return x[0] + x[1] }, a, b)
+}
+
+// Sub returns a - b.
+func Sub(a, b *bitscope.Trace) (*bitscope.Trace, error) {
+	if unit(a) != unit(b) {
+		return nil, ErrUnit
+	}
+	return derive(unit(a), func(x []float64) float64 { return x[0] - x[1] }, a, b)
+}
+
+// Mul returns a × b, with the product of their units (V² for volts).
+func Mul(a, b *bitscope.Trace) (*bitscope.Trace, error) {
+	u := unit(a) + "·" + unit(b)
+	if unit(a) == unit(b) {
+		u = unit(a) + "²"
+	}
+	return derive(u, func(x []float64) float64 { return x[0] * x[1] }, a, b)
+}
+
+// Invert returns -t.
+func Invert(t *bitscope.Trace) (*bitscope.Trace, error) {
+	return Scale(t, -1, 0, "")
+}
+
+// Scale returns k t + offset, in the given unit, or in the unit of t if
+// it is empty. For example, Scale(t, 10, 0, "A") converts the voltage across
+// a 0.1 Ω shunt to a current.
+func Scale(t *bitscope.Trace, k, offset float64, u string) (*bitscope.Trace, error) {
+	if u == "" {
+		u = unit(t)
+	}
+	return derive(u, func(x []float64) float64 { return k*x[0] + offset }, t)
+}
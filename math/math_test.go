@@ -0,0 +1,49 @@
+// For the license see the LICENSE file (BSD style)
+
+package math_test
+
+import (
+	"fmt"
+	gomath "math"
+
+	"bitscope"
+	"bitscope/math"
+	"bitscope/measure"
+)
+
+func Example() {
+	// Two probes on a differential pair, swinging by 1 V in opposition
+	a := &bitscope.Trace{SampleRate: 1e6, Range: 5.1, Samples: []byte{150, 100, 150, 100}}
+	b := &bitscope.Trace{SampleRate: 1e6, Range: 5.1, Samples: []byte{100, 150, 100, 150}}
+
+	d, _ := math.Sub(a, b)
+	vpp, _ := measure.Vpp(d)
+	fmt.Printf("%.2f %s %.2f\n", d.Values, d.Unit, vpp)
+
+	p, err := math.Mul(a, a)
+	fmt.Println(p.Unit, err)
+	// Output:
+	// [1.00 -1.00 1.00 -1.00] V 2.00
+	// V² <nil>
+}
+
+func ExampleNotch() {
+	// 1 V of 50 Hz hum on a 0.5 V step, sampled at 10 kHz
+	t := &bitscope.Trace{SampleRate: 1e4, Values: make([]float64, 4000)}
+	for i := range t.Values {
+		t.Values[i] = gomath.Sin(2 * gomath.Pi * 50 * float64(i) / 1e4)
+		if i >= 2000 {
+			t.Values[i] += 0.5
+		}
+	}
+
+	n, _ := math.Notch(t, 50, 2)
+	a, err := math.MovingAverage(t, 50)
+	fmt.Printf("%.2f %.2f %.2f %.2f %v\n", n.Values[1900], n.Values[3900], a.Values[1900], a.Values[3900], err)
+
+	_, err = math.LowPass(&bitscope.Trace{SampleRate: 1e4, Values: []float64{}}, 50)
+	fmt.Println(err)
+	// Output:
+	// 0.00 0.50 0.00 0.50 <nil>
+	// Empty trace
+}
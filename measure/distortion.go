@@ -0,0 +1,117 @@
+// For the license see the LICENSE file (BSD style)
+
+package measure
+
+import (
+	"math"
+
+	"bitscope"
+)
+
+// Number of harmonics (including the fundamental) taken as distortion
+const harmonics = 10
+
+// sine holds the power (in V²) of the fundamental of a sine, of its
+// harmonics, and of the rest of the spectrum except DC.
+type sine struct {
+	fund, harm, noise float64
+}
+
+// analyzeSine splits the spectrum of a trace of a sine.
+func analyzeSine(t *bitscope.Trace) (*sine, error) {
+
+	s, err := Spectrum(t, Blackman)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(s.Vrms)
+	w := s.lobe
+
+	// Power of the component at bin k, over its main lobe
+	used := make([]bool, n)
+	power := func(k int) float64 {
+		p := 0.0
+		for i := k - w; i <= k+w; i++ {
+			if i >= 0 && i < n && !used[i] {
+				p += s.Vrms[i] * s.Vrms[i]
+				used[i] = true
+			}
+		}
+		return p / s.ENBW
+	}
+
+	// DC and the lobe around it are left out
+	power(0)
+
+	f := w + 1
+	for k := f; k < n; k++ {
+		if s.Vrms[k] > s.Vrms[f] {
+			f = k
+		}
+	}
+	if f >= n-1 {
+		return nil, ErrNoPeriod
+	}
+
+	var a sine
+	a.fund = power(f)
+	for h := 2; h <= harmonics && h*f < n; h++ {
+		a.harm += power(h * f)
+	}
+	for k := 0; k < n; k++ {
+		if !used[k] {
+			a.noise += s.Vrms[k] * s.Vrms[k]
+		}
+	}
+	a.noise /= s.ENBW
+	return &a, nil
+}
+
+// THD returns the total harmonic distortion of a trace of a sine, as the
+// ratio in dB of the rms voltages of its harmonics (up to the 10th) and of
+// its fundamental.
+func THD(t *bitscope.Trace) (float64, error) {
+	a, err := analyzeSine(t)
+	if err != nil {
+		return 0, err
+	}
+	return db(a.harm / a.fund), nil
+}
+
+// SNR returns the signal to noise ratio of a trace of a sine, in dB: the
+// ratio of the power of the fundamental to that of the rest of the
+// spectrum, except DC and the harmonics.
+func SNR(t *bitscope.Trace) (float64, error) {
+	a, err := analyzeSine(t)
+	if err != nil {
+		return 0, err
+	}
+	return db(a.fund / a.noise), nil
+}
+
+// SINAD returns the signal to noise and distortion ratio of a trace of a
+// sine, in dB: as SNR, but with the harmonics counted as noise.
+func SINAD(t *bitscope.Trace) (float64, error) {
+	a, err := analyzeSine(t)
+	if err != nil {
+		return 0, err
+	}
+	return db(a.fund / (a.noise + a.harm)), nil
+}
+
+// ENOB returns the effective number of bits of the conversion of a trace of
+// a sine, from its SINAD. It is only meaningful for a sine that spans the
+// whole vertical range.
+func ENOB(t *bitscope.Trace) (float64, error) {
+	s, err := SINAD(t)
+	if err != nil {
+		return 0, err
+	}
+	return (s - 1.76) / 6.02, nil
+}
+
+// db converts a power ratio to dB.
+func db(r float64) float64 {
+	return 10 * math.Log10(r)
+}
@@ -0,0 +1,136 @@
+// For the license see the LICENSE file (BSD style)
+
+package measure
+
+import (
+	"errors"
+
+	"bitscope"
+)
+
+// Refs are the reference levels of the edge measurements, as fractions of
+// the amplitude above the base level (see Levels).
+type Refs struct {
+	Low, Mid, High float64
+}
+
+// DefaultRefs are the usual 10%, 50% and 90% reference levels.
+var DefaultRefs = Refs{0.1, 0.5, 0.9}
+
+// ErrNoEdge is returned when the trace lacks the edges needed for a
+// measurement.
+var ErrNoEdge = errors.New("No complete edge in trace")
+
+// edges holds the reference levels of a trace, and its samples in volts.
+type edges struct {
+	v              []float64
+	low, mid, high float64
+	h              float64
+	rate           float64
+}
+
+func newEdges(t *bitscope.Trace, r Refs) (*edges, error) {
+
+	if t.SampleRate == 0 {
+		return nil, ErrNoRate
+	}
+	if r.Low >= r.Mid || r.Mid >= r.High {
+		return nil, errors.New("Invalid reference levels")
+	}
+	base, top, err := Levels(t)
+	if err != nil {
+		return nil, err
+	}
+	a := top - base
+	v, _ := volts(t)
+
+	return &edges{
+		v:    v,
+		low:  base + r.Low*a,
+		mid:  base + r.Mid*a,
+		high: base + r.High*a,
+		// Less than the distance between the levels and the extremes
+		h:    a / 50,
+		rate: t.SampleRate,
+	}, nil
+}
+
+// transitions returns the mean time, in seconds, from the crossings of
+// level a in direction ra to the following crossings of level b in
+// direction rb, taking only the last crossing of a before each one of b.
+func (e *edges) transitions(a float64, ra bool, b float64, rb bool) (float64, error) {
+
+	xa := crossings(e.v, a, e.h, ra)
+	xb := crossings(e.v, b, e.h, rb)
+
+	sum, n, i := 0.0, 0, 0
+	for _, x := range xb {
+		last := -1.0
+		for i < len(xa) && xa[i] < x {
+			last = xa[i]
+			i++
+		}
+		if last >= 0 {
+			sum += x - last
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, ErrNoEdge
+	}
+	return sum / float64(n) / e.rate, nil
+}
+
+// RiseTime returns the mean time that the rising edges take from the low
+// to the high reference level, in seconds.
+func RiseTime(t *bitscope.Trace, r Refs) (float64, error) {
+	e, err := newEdges(t, r)
+	if err != nil {
+		return 0, err
+	}
+	return e.transitions(e.low, true, e.high, true)
+}
+
+// FallTime returns the mean time that the falling edges take from the high
+// to the low reference level, in seconds.
+func FallTime(t *bitscope.Trace, r Refs) (float64, error) {
+	e, err := newEdges(t, r)
+	if err != nil {
+		return 0, err
+	}
+	return e.transitions(e.high, false, e.low, false)
+}
+
+// PositiveWidth returns the mean width of the positive pulses, from a
+// rising to a falling crossing of the middle reference level, in seconds.
+func PositiveWidth(t *bitscope.Trace, r Refs) (float64, error) {
+	e, err := newEdges(t, r)
+	if err != nil {
+		return 0, err
+	}
+	return e.transitions(e.mid, true, e.mid, false)
+}
+
+// NegativeWidth returns the mean width of the negative pulses, from a
+// falling to a rising crossing of the middle reference level, in seconds.
+func NegativeWidth(t *bitscope.Trace, r Refs) (float64, error) {
+	e, err := newEdges(t, r)
+	if err != nil {
+		return 0, err
+	}
+	return e.transitions(e.mid, false, e.mid, true)
+}
+
+// DutyCycle returns the fraction of the period in which the signal is
+// above the middle reference level.
+func DutyCycle(t *bitscope.Trace, r Refs) (float64, error) {
+	pos, err := PositiveWidth(t, r)
+	if err != nil {
+		return 0, err
+	}
+	neg, err := NegativeWidth(t, r)
+	if err != nil {
+		return 0, err
+	}
+	return pos / (pos + neg), nil
+}
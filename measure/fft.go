@@ -0,0 +1,51 @@
+// For the license see the LICENSE file (BSD style)
+
+package measure
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// fft returns the discrete Fourier transform of x, whose length must be a
+// power of 2 (radix 2, decimation in time).
+func fft(x []complex128) []complex128 {
+
+	n := len(x)
+	y := make([]complex128, n)
+
+	// Bit reversed order
+	bits := 0
+	for 1<<uint(bits) < n {
+		bits++
+	}
+	for i := range x {
+		j := 0
+		for b := 0; b < bits; b++ {
+			j |= (i >> uint(b) & 1) << uint(bits-1-b)
+		}
+		y[j] = x[i]
+	}
+
+	for size := 2; size <= n; size *= 2 {
+		w := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := y[start+k], wk*y[start+k+size/2]
+				y[start+k], y[start+k+size/2] = a+b, a-b
+				wk *= w
+			}
+		}
+	}
+	return y
+}
+
+// pow2 returns the smallest power of 2 not below n.
+func pow2(n int) int {
+	p := 1
+	for p < n {
+		p *= 2
+	}
+	return p
+}
@@ -0,0 +1,96 @@
+// For the license see the LICENSE file (BSD style)
+
+package measure
+
+import (
+	"errors"
+	"math"
+
+	"bitscope"
+)
+
+// Errors of the timing measurements
+var (
+	ErrNoRate   = errors.New("Trace without sample rate")
+	ErrNoPeriod = errors.New("Less than one period in trace")
+)
+
+// Period returns the period of the signal in seconds, and its uncertainty.
+// It is the mean time between the rising crossings of the middle level
+// (halfway between Min and Max), interpolated between samples, with some
+// hysteresis against noise. The uncertainty allows for half a sample of
+// error in the first and last crossing.
+func Period(t *bitscope.Trace) (p, dp float64, err error) {
+
+	if t.SampleRate == 0 {
+		return 0, 0, ErrNoRate
+	}
+	v, err := volts(t)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	lo, hi := extremes(v)
+	x := crossings(v, (lo+hi)/2, (hi-lo)/10, true)
+	n := len(x) - 1
+	if n < 1 {
+		return 0, 0, ErrNoPeriod
+	}
+
+	p = (x[n] - x[0]) / float64(n) / t.SampleRate
+	dp = 1 / float64(n) / t.SampleRate
+	return p, dp, nil
+}
+
+// Frequency returns the frequency of the signal in Hz, and its
+// uncertainty (see Period).
+func Frequency(t *bitscope.Trace) (f, df float64, err error) {
+	p, dp, err := Period(t)
+	if err != nil {
+		return 0, 0, err
+	}
+	return 1 / p, dp / (p * p), nil
+}
+
+// extremes returns the lowest and highest values of v.
+func extremes(v []float64) (lo, hi float64) {
+	lo, hi = v[0], v[0]
+	for _, x := range v {
+		lo, hi = math.Min(lo, x), math.Max(hi, x)
+	}
+	return lo, hi
+}
+
+// crossings returns the positions, in samples, at which v crosses level
+// upwards (rising) or downwards, interpolated linearly between the samples
+// on both sides. A crossing only counts after v has been beyond the level
+// by the hysteresis h in the other direction, and is beyond it by h in
+// the direction of the crossing.
+func crossings(v []float64, level, h float64, rising bool) []float64 {
+
+	// Work on rising crossings only
+	s := 1.0
+	if !rising {
+		s, level = -1, -level
+	}
+
+	var res []float64
+	armed := false
+	for i, x := range v {
+		x *= s
+		switch {
+		case x < level-h:
+			armed = true
+		case armed && x > level+h:
+			armed = false
+			// The last sample at or below the level
+			j := i - 1
+			for j > 0 && v[j]*s > level {
+				j--
+			}
+			a, b := v[j]*s, v[j+1]*s
+			res = append(res, float64(j)+(level-a)/(b-a))
+		}
+	}
+	return res
+}
@@ -0,0 +1,87 @@
+// For the license see the LICENSE file (BSD style)
+
+package measure
+
+import (
+	"math"
+
+	"bitscope"
+)
+
+// JitterStats describes the timing stability of a clock.
+type JitterStats struct {
+	// Times of the rising edges, in seconds from the first sample, and the
+	// periods between them
+	Edges   []float64
+	Periods []float64
+	// Mean, standard deviation and peak to peak variation of the period
+	Mean, RMS, PeakToPeak float64
+	// RMS and largest difference between consecutive periods
+	CycleToCycle, CycleToCycleMax float64
+	// Time interval error of each edge: its time minus that of the ideal
+	// clock that best fits the edges (least squares)
+	TIE []float64
+}
+
+// Jitter measures the jitter of a clock signal from the times of its
+// rising edges, at the crossings of the middle level interpolated between
+// samples (see Period). At least three edges are needed. With 8 bit
+// samples, the timing resolution is a small fraction of a sample period
+// for edges that take several samples.
+func Jitter(t *bitscope.Trace) (*JitterStats, error) {
+
+	if t.SampleRate == 0 {
+		return nil, ErrNoRate
+	}
+	v, err := volts(t)
+	if err != nil {
+		return nil, err
+	}
+
+	lo, hi := extremes(v)
+	x := crossings(v, (lo+hi)/2, (hi-lo)/10, true)
+	if len(x) < 3 {
+		return nil, ErrNoPeriod
+	}
+
+	j := &JitterStats{Edges: make([]float64, len(x))}
+	for i := range x {
+		j.Edges[i] = x[i] / t.SampleRate
+	}
+
+	for i := 1; i < len(x); i++ {
+		j.Periods = append(j.Periods, j.Edges[i]-j.Edges[i-1])
+	}
+	j.Mean = mean(j.Periods)
+	pmin, pmax := extremes(j.Periods)
+	j.PeakToPeak = pmax - pmin
+	for _, p := range j.Periods {
+		j.RMS += (p - j.Mean) * (p - j.Mean)
+	}
+	j.RMS = math.Sqrt(j.RMS / float64(len(j.Periods)))
+
+	for i := 1; i < len(j.Periods); i++ {
+		d := j.Periods[i] - j.Periods[i-1]
+		j.CycleToCycle += d * d
+		j.CycleToCycleMax = math.Max(j.CycleToCycleMax, math.Abs(d))
+	}
+	j.CycleToCycle = math.Sqrt(j.CycleToCycle / float64(len(j.Periods)-1))
+
+	// Ideal clock: edge i at a + b i
+	n := float64(len(x))
+	si, st, sii, sit := 0.0, 0.0, 0.0, 0.0
+	for i, e := range j.Edges {
+		fi := float64(i)
+		si += fi
+		st += e
+		sii += fi * fi
+		sit += fi * e
+	}
+	b := (n*sit - si*st) / (n*sii - si*si)
+	a := (st - b*si) / n
+	j.TIE = make([]float64, len(x))
+	for i, e := range j.Edges {
+		j.TIE[i] = e - (a + b*float64(i))
+	}
+	return j, nil
+}
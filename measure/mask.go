@@ -0,0 +1,88 @@
+// For the license see the LICENSE file (BSD style)
+
+package measure
+
+import (
+	"errors"
+	"math"
+
+	"bitscope"
+)
+
+// Mask is a pass/fail envelope for traces: each sample must lie between the
+// lower and upper limits of its index.
+type Mask struct {
+	Lower, Upper []float64
+}
+
+// Violation is a sample of a trace outside of a mask.
+type Violation struct {
+	Index int
+	Value float64
+	// The limit that was crossed
+	Limit float64
+}
+
+// NewMask returns a mask with the given limits.
+func NewMask(lower, upper []float64) (*Mask, error) {
+	if len(lower) != len(upper) {
+		return nil, errors.New("Mask limits of different length")
+	}
+	for i := range lower {
+		if lower[i] > upper[i] {
+			return nil, errors.New("Mask lower limit above upper limit")
+		}
+	}
+	return &Mask{lower, upper}, nil
+}
+
+// GoldenMask returns a mask around a trace of a good unit: the limits are
+// the lowest and highest values of the golden trace within shift samples of
+// each index, to allow for some jitter, widened by tol.
+func GoldenMask(golden *bitscope.Trace, tol float64, shift int) (*Mask, error) {
+
+	if tol < 0 || shift < 0 {
+		return nil, errors.New("Invalid mask tolerance")
+	}
+	v, err := volts(golden)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Mask{make([]float64, len(v)), make([]float64, len(v))}
+	for i := range v {
+		lo, hi := v[i], v[i]
+		for j := i - shift; j <= i+shift; j++ {
+			if j >= 0 && j < len(v) {
+				lo, hi = math.Min(lo, v[j]), math.Max(hi, v[j])
+			}
+		}
+		m.Lower[i], m.Upper[i] = lo-tol, hi+tol
+	}
+	return m, nil
+}
+
+// Test checks a trace against the mask, and returns the samples outside of
+// it, in order; the trace passes if there are none. The trace must have as
+// many samples as the mask.
+func (m *Mask) Test(t *bitscope.Trace) ([]Violation, error) {
+
+	v, err := volts(t)
+	if err != nil {
+		return nil, err
+	}
+	if len(v) != len(m.Lower) {
+		return nil, errors.New("Trace and mask of different length")
+	}
+
+	var res []Violation
+	for i, x := range v {
+		switch {
+		case x < m.Lower[i]:
+			res = append(res, Violation{i, x, m.Lower[i]})
+		case x > m.Upper[i]:
+			res = append(res, Violation{i, x, m.Upper[i]})
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,159 @@
+// For the license see the LICENSE file (BSD style)
+
+// Package measure computes the usual oscilloscope measurements on the
+// traces acquired with package bitscope. The results are in volts at the
+// probe tip, as given by Trace.Volts, so the traces must have a vertical
+// range; for derived traces they are in the unit of their values.
+package measure
+
+import (
+	"errors"
+	"math"
+
+	"bitscope"
+)
+
+// ErrNoVolts is returned for a trace without samples or vertical range.
+var ErrNoVolts = errors.New("Trace cannot be converted to volts")
+
+// volts returns the samples of t in volts.
+func volts(t *bitscope.Trace) ([]float64, error) {
+	v := t.Volts()
+	if len(v) == 0 {
+		return nil, ErrNoVolts
+	}
+	return v, nil
+}
+
+// Min returns the lowest sample.
+func Min(t *bitscope.Trace) (float64, error) {
+	v, err := volts(t)
+	if err != nil {
+		return 0, err
+	}
+	m := v[0]
+	for _, x := range v {
+		m = math.Min(m, x)
+	}
+	return m, nil
+}
+
+// Max returns the highest sample.
+func Max(t *bitscope.Trace) (float64, error) {
+	v, err := volts(t)
+	if err != nil {
+		return 0, err
+	}
+	m := v[0]
+	for _, x := range v {
+		m = math.Max(m, x)
+	}
+	return m, nil
+}
+
+// Vpp returns the peak to peak voltage: Max - Min.
+func Vpp(t *bitscope.Trace) (float64, error) {
+	lo, err := Min(t)
+	if err != nil {
+		return 0, err
+	}
+	hi, _ := Max(t)
+	return hi - lo, nil
+}
+
+// Mean returns the average of the samples (the DC level).
+func Mean(t *bitscope.Trace) (float64, error) {
+	v, err := volts(t)
+	if err != nil {
+		return 0, err
+	}
+	return mean(v), nil
+}
+
+func mean(v []float64) float64 {
+	s := 0.0
+	for _, x := range v {
+		s += x
+	}
+	return s / float64(len(v))
+}
+
+// Vrms returns the root mean square of the samples, including the DC
+// level.
+func Vrms(t *bitscope.Trace) (float64, error) {
+	v, err := volts(t)
+	if err != nil {
+		return 0, err
+	}
+	s := 0.0
+	for _, x := range v {
+		s += x * x
+	}
+	return math.Sqrt(s / float64(len(v))), nil
+}
+
+// Levels returns the base and top levels of the signal: the most common
+// values below and above the middle of its range. For pulses they are the
+// low and high states, unaffected by overshoot and ringing; for signals
+// without flat parts, such as a sine, they tend to the extremes.
+func Levels(t *bitscope.Trace) (base, top float64, err error) {
+
+	v, err := volts(t)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// The samples have 256 possible values, one per ADC code. Derived
+	// traces are divided in as many bins.
+	codes := t.Samples
+	if t.Values != nil {
+		codes = quantize(v)
+	}
+
+	var hist [256]int
+	var val [256]float64
+	lo, hi := 255, 0
+	for i, c := range codes {
+		hist[c]++
+		val[c] = v[i]
+		if int(c) < lo {
+			lo = int(c)
+		}
+		if int(c) > hi {
+			hi = int(c)
+		}
+	}
+
+	mid := (lo + hi) / 2
+	b, tp := lo, hi
+	for c := lo; c <= mid; c++ {
+		if hist[c] > hist[b] {
+			b = c
+		}
+	}
+	for c := hi; c > mid; c-- {
+		if hist[c] > hist[tp] {
+			tp = c
+		}
+	}
+	return val[b], val[tp], nil
+}
+
+// quantize maps the range of v onto 256 codes.
+func quantize(v []float64) []byte {
+	lo, hi := extremes(v)
+	c := make([]byte, len(v))
+	if hi > lo {
+		for i, x := range v {
+			c[i] = byte(math.Round(255 * (x - lo) / (hi - lo)))
+		}
+	}
+	return c
+}
+
+// Amplitude returns the difference between the top and base levels (see
+// Levels).
+func Amplitude(t *bitscope.Trace) (float64, error) {
+	base, top, err := Levels(t)
+	return top - base, err
+}
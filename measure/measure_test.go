@@ -0,0 +1,230 @@
+// For the license see the LICENSE file (BSD style)
+
+package measure_test
+
+import (
+	"fmt"
+	"math"
+
+	"bitscope"
+	"bitscope/measure"
+)
+
+// square returns a trace of a square wave of period p samples, from code
+// lo to hi, on a range of 5.1 V (0.02 V per code).
+func square(n, p int, lo, hi byte) *bitscope.Trace {
+	t := &bitscope.Trace{Samples: make([]byte, n), SampleRate: 1e6, Range: 5.1}
+	for i := range t.Samples {
+		t.Samples[i] = lo
+		if i%p < p/2 {
+			t.Samples[i] = hi
+		}
+	}
+	return t
+}
+
+func Example() {
+	t := square(1000, 100, 78, 178)
+	// Overshoot at the first edge
+	t.Samples[0] = 188
+
+	vpp, _ := measure.Vpp(t)
+	amp, _ := measure.Amplitude(t)
+	mean, _ := measure.Mean(t)
+	rms, _ := measure.Vrms(t)
+	fmt.Printf("%.2f %.2f %.3f %.3f\n", vpp, amp, mean, rms)
+	// Output: 2.20 2.00 0.010 1.000
+}
+
+func ExampleFrequency() {
+	// 1 kHz sine sampled at 1 MHz
+	t := &bitscope.Trace{Samples: make([]byte, 5000), SampleRate: 1e6, Range: 5.1}
+	for i := range t.Samples {
+		t.Samples[i] = byte(math.Round(128 + 100*math.Sin(2*math.Pi*float64(i)/1000)))
+	}
+
+	f, df, err := measure.Frequency(t)
+	fmt.Printf("%.1f %.2f %v\n", f, df, err)
+	// Output: 1000.0 0.33 <nil>
+}
+
+func ExampleRiseTime() {
+	// Pulses with edges of 10 samples at 1 MHz, 30% duty cycle
+	t := &bitscope.Trace{SampleRate: 1e6, Range: 5.1}
+	for i := 0; i < 1000; i++ {
+		k := i % 100
+		switch {
+		case k < 10:
+			t.Samples = append(t.Samples, byte(28+20*k))
+		case k < 30:
+			t.Samples = append(t.Samples, 228)
+		case k < 40:
+			t.Samples = append(t.Samples, byte(228-20*(k-30)))
+		default:
+			t.Samples = append(t.Samples, 28)
+		}
+	}
+
+	rise, _ := measure.RiseTime(t, measure.DefaultRefs)
+	fall, _ := measure.FallTime(t, measure.DefaultRefs)
+	duty, err := measure.DutyCycle(t, measure.DefaultRefs)
+	fmt.Printf("%.1fus %.1fus %.2f %v\n", rise*1e6, fall*1e6, duty, err)
+	// Output: 8.0us 8.0us 0.30 <nil>
+}
+
+func ExampleSpectrum() {
+	// 1 Vrms at 10 kHz sampled at 1 MHz, on a range of 5 V
+	t := &bitscope.Trace{Samples: make([]byte, 4096), SampleRate: 1e6, Range: 5}
+	for i := range t.Samples {
+		v := math.Sqrt2 * math.Sin(2*math.Pi*float64(i)/100)
+		t.Samples[i] = byte(math.Round((v/5 + 0.5) * 255))
+	}
+
+	s, err := measure.Spectrum(t, measure.Blackman)
+	k := 0
+	for i := range s.DBV {
+		if s.DBV[i] > s.DBV[k] {
+			k = i
+		}
+	}
+	fmt.Printf("%.0f Hz %.2f dBV %v\n", s.Freq[k], s.DBV[k], err)
+	// Output: 10010 Hz -0.02 dBV <nil>
+}
+
+func ExampleENOB() {
+	// Full scale sine with 1% of third harmonic, quantized to 8 bits
+	t := &bitscope.Trace{Samples: make([]byte, 8192), SampleRate: 1e6, Range: 5}
+	for i := range t.Samples {
+		x := 2 * math.Pi * float64(i) / 97.3
+		v := 0.99*math.Sin(x) + 0.0099*math.Sin(3*x)
+		t.Samples[i] = byte(math.Round(127.5 + 127.5*v))
+	}
+
+	thd, _ := measure.THD(t)
+	snr, _ := measure.SNR(t)
+	enob, err := measure.ENOB(t)
+	fmt.Printf("%.0f dB %.0f dB %.1f bits %v\n", thd, snr, enob, err)
+	// Output: -40 dB 49 dB 6.2 bits <nil>
+}
+
+func ExamplePhase() {
+	// b lags a by 30 degrees
+	a := &bitscope.Trace{Samples: make([]byte, 2000), SampleRate: 1e6, Range: 5}
+	b := &bitscope.Trace{Samples: make([]byte, 2000), SampleRate: 1e6, Range: 5}
+	for i := range a.Samples {
+		x := 2 * math.Pi * float64(i) / 150
+		a.Samples[i] = byte(math.Round(128 + 100*math.Sin(x)))
+		b.Samples[i] = byte(math.Round(128 + 50*math.Sin(x-math.Pi/6)))
+	}
+
+	p, err := measure.Phase(a, b)
+	fmt.Printf("%.1f %v\n", p, err)
+
+	// Derived traces of different lengths
+	c := &bitscope.Trace{SampleRate: 1e6, Values: a.Volts()}
+	d := &bitscope.Trace{SampleRate: 1e6, Values: b.Volts()[:400]}
+	_, err = measure.Phase(c, d)
+	fmt.Println(err)
+	// Output:
+	// -30.0 <nil>
+	// Traces with different sampling
+}
+
+func ExamplePeaks() {
+	t := &bitscope.Trace{SampleRate: 1e6, Values: make([]float64, 100)}
+	// A glitch of 3 us, and a spike of 1 us
+	t.Values[20], t.Values[21], t.Values[22] = 0.8, 1.5, 0.9
+	t.Values[60] = 2
+
+	p, err := measure.Peaks(t, 0.5, 2e-6)
+	fmt.Printf("%+v %v\n", p, err)
+	// Output: [{Index:21 Amplitude:1.5 Width:3e-06}] <nil>
+}
+
+func ExampleStats() {
+	var s measure.Stats
+	for _, v := range [][]float64{{1, 2, 3}, {1, 4, 5}} {
+		s.Add(&bitscope.Trace{Values: v})
+	}
+	fmt.Println(s.N(), s.Mean(), s.StdDev())
+
+	h, err := measure.Histogram(&bitscope.Trace{Values: []float64{0, 0.1, 0.5, 0.9, 1}}, 2)
+	fmt.Println(h.Counts, h.Center(0), err)
+	// Output:
+	// 2 [1 3 4] [0 1 1]
+	// [2 3] 0.25 <nil>
+}
+
+func ExampleGoldenMask() {
+	golden := &bitscope.Trace{Values: []float64{0, 0, 1, 1, 1, 0, 0}}
+	m, _ := measure.GoldenMask(golden, 0.1, 1)
+
+	// Late rising edge passes, the dip at index 3 fails
+	v, err := m.Test(&bitscope.Trace{Values: []float64{0, 0, 0, 1, 1, 0, 0}})
+	fmt.Println(v, err)
+	v, err = m.Test(&bitscope.Trace{Values: []float64{0, 0, 1, 0.5, 1, 0, 0}})
+	fmt.Println(v, err)
+	// Output:
+	// [] <nil>
+	// [{3 0.5 0.9}] <nil>
+}
+
+func ExampleMeasure() {
+	r, err := measure.Measure(square(1000, 100, 78, 178), measure.All)
+	fmt.Printf("%.2f %.2f %.0f %.2f %v\n", r.Vpp, r.Amplitude, r.Frequency, r.DutyCycle, err)
+
+	r, _ = measure.Measure(square(1000, 100, 78, 178), measure.Voltage)
+	fmt.Printf("%.3f %.2f %.2f\n", r.Mean, r.StdDev, r.Frequency)
+	// Output:
+	// 2.00 2.00 10000 0.50 <nil>
+	// 0.010 1.00 NaN
+}
+
+func ExampleJitter() {
+	// Square wave with a period of 100 samples at 1 MHz, with a rising
+	// edge 2 samples late
+	t := &bitscope.Trace{SampleRate: 1e6, Values: make([]float64, 1000)}
+	for i := range t.Values {
+		k := i % 100
+		if i >= 500 && i < 600 {
+			k = (i - 2) % 100
+		}
+		if k >= 50 {
+			t.Values[i] = 1
+		}
+	}
+
+	j, err := measure.Jitter(t)
+	fmt.Printf("%.1fus %.2fus %.1fus %v\n", j.Mean*1e6, j.PeakToPeak*1e6, j.CycleToCycleMax*1e6, err)
+	// Output: 100.0us 4.00us 4.0us <nil>
+}
+
+func ExampleStepResponse() {
+	// Step from 0 to 1 V at sample 100, ringing down with a period of 20
+	// samples, at 1 MHz
+	t := &bitscope.Trace{SampleRate: 1e6, Values: make([]float64, 1000)}
+	for i := 100; i < len(t.Values); i++ {
+		k := float64(i - 100)
+		t.Values[i] = 1 - math.Exp(-k/30)*math.Cos(2*math.Pi*k/20)
+	}
+
+	s, err := measure.StepResponse(t, 0.02)
+	fmt.Printf("%.1f %.1f %.0f%% %.0f%% %.0fus %v\n", s.Initial, s.Final, s.Overshoot, s.Undershoot, s.Settling*1e6, err)
+	// Output: 0.0 1.0 72% 51% 109us <nil>
+}
+
+func ExamplePower() {
+	// 10 Vpk across a load that draws 2 Apk, lagging by 60°, measured with
+	// a 0.1 Ω shunt
+	v := &bitscope.Trace{SampleRate: 1e4, Values: make([]float64, 1050)}
+	i := &bitscope.Trace{SampleRate: 1e4, Values: make([]float64, 1050)}
+	for k := range v.Values {
+		w := 2 * math.Pi * float64(k) / 100
+		v.Values[k] = 10 * math.Sin(w)
+		i.Values[k] = 0.2 * math.Sin(w-math.Pi/3)
+	}
+
+	p, err := measure.Power(v, i, 10)
+	fmt.Printf("%.2fV %.2fA %.2fW %.2fVA %.2f %s %v\n", p.Vrms, p.Irms, p.Real, p.Apparent, p.Factor, p.Instant.Unit, err)
+	// Output: 7.07V 1.41A 5.00W 10.00VA 0.50 W <nil>
+}
@@ -0,0 +1,66 @@
+// For the license see the LICENSE file (BSD style)
+
+package measure
+
+import "bitscope"
+
+// Peak is an excursion of a signal beyond a threshold.
+type Peak struct {
+	// Index and value of the most extreme sample
+	Index     int
+	Amplitude float64
+	// Time spent beyond the threshold, in seconds
+	Width float64
+}
+
+// Peaks returns the excursions of the signal beyond threshold that last at
+// least minWidth seconds, in order. They are above the threshold if it is
+// above the mean of the trace, and below it otherwise, to find negative
+// glitches. An excursion still in progress at the end of the trace is
+// included.
+func Peaks(t *bitscope.Trace, threshold, minWidth float64) ([]Peak, error) {
+
+	if t.SampleRate == 0 {
+		return nil, ErrNoRate
+	}
+	v, err := volts(t)
+	if err != nil {
+		return nil, err
+	}
+
+	// Work on positive excursions only
+	s := 1.0
+	if threshold < mean(v) {
+		s = -1
+	}
+
+	var res []Peak
+	start := -1
+	add := func(end int) {
+		p := Peak{Index: start}
+		for i := start; i < end; i++ {
+			if v[i]*s > v[p.Index]*s {
+				p.Index = i
+			}
+		}
+		p.Amplitude = v[p.Index]
+		p.Width = float64(end-start) / t.SampleRate
+		if p.Width >= minWidth {
+			res = append(res, p)
+		}
+	}
+
+	for i, x := range v {
+		switch {
+		case x*s > threshold*s && start < 0:
+			start = i
+		case x*s <= threshold*s && start >= 0:
+			add(i)
+			start = -1
+		}
+	}
+	if start >= 0 {
+		add(len(v))
+	}
+	return res, nil
+}
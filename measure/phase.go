@@ -0,0 +1,57 @@
+// For the license see the LICENSE file (BSD style)
+
+package measure
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+
+	"bitscope"
+)
+
+// Phase returns the phase of the fundamental of b relative to that of a,
+// in degrees from -180 to 180: positive if b leads. The traces must have
+// been sampled at the same times, with the same rate; the frequency is
+// measured on a (see Frequency), and the phases are taken with a DFT at
+// that frequency over a whole number of its periods.
+//
+// The traces of AcquireDual are not sampled at the same times: CHB is
+// sampled half a sample after CHA, so that with a as CHA, b appears to
+// lead by an extra 180·f/SampleRate degrees at frequency f, which is to be
+// subtracted.
+func Phase(a, b *bitscope.Trace) (float64, error) {
+
+	if a.SampleRate != b.SampleRate {
+		return 0, errors.New("Traces with different sampling")
+	}
+	p, _, err := Period(a)
+	if err != nil {
+		return 0, err
+	}
+	va, _ := volts(a)
+	vb, err := volts(b)
+	if err != nil {
+		return 0, err
+	}
+	if len(va) != len(vb) {
+		return 0, errors.New("Traces with different sampling")
+	}
+
+	// Angular frequency in radians per sample, and whole periods
+	w := 2 * math.Pi / (p * a.SampleRate)
+	n := int(math.Floor(float64(len(va))*w/(2*math.Pi)) * 2 * math.Pi / w)
+
+	r := tone(vb[:n], w) / tone(va[:n], w)
+	return cmplx.Phase(r) * 180 / math.Pi, nil
+}
+
+// tone returns the complex amplitude of the component of angular frequency
+// w (radians per sample) of v.
+func tone(v []float64, w float64) complex128 {
+	var s complex128
+	for k, x := range v {
+		s += complex(x, 0) * cmplx.Exp(complex(0, -w*float64(k)))
+	}
+	return s * complex(2/float64(len(v)), 0)
+}
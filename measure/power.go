@@ -0,0 +1,86 @@
+// For the license see the LICENSE file (BSD style)
+
+package measure
+
+import (
+	"errors"
+	"math"
+
+	"bitscope"
+)
+
+// PowerStats is the result of a power analysis.
+type PowerStats struct {
+	// Instantaneous power, in W (a derived trace, see Trace.Values)
+	Instant *bitscope.Trace
+	// RMS voltage and current
+	Vrms, Irms float64
+	// Real (mean) power in W, apparent power (Vrms × Irms) in VA, and
+	// power factor (their ratio)
+	Real, Apparent, Factor float64
+}
+
+// Power analyzes the power delivered to a load from a trace of its voltage
+// and one of its current, such as those of CHA and CHB of AcquireDual. The
+// current trace is converted to amperes with ratio, in A/V (10 for the
+// voltage across a 0.1 Ω shunt); a trace in A (see package math) is taken
+// as is.
+//
+// The statistics are computed over a whole number of periods of the
+// voltage when the trace holds at least one, so that the real power of
+// periodic signals does not depend on where the trace starts.
+func Power(v, i *bitscope.Trace, ratio float64) (*PowerStats, error) {
+
+	if v.SampleRate != i.SampleRate {
+		return nil, errors.New("Traces with different sampling")
+	}
+	vv, err := volts(v)
+	if err != nil {
+		return nil, err
+	}
+	vi, err := volts(i)
+	if err != nil {
+		return nil, err
+	}
+	if len(vi) != len(vv) {
+		return nil, errors.New("Traces with different sampling")
+	}
+	if i.Unit == "A" {
+		ratio = 1
+	}
+
+	p := &PowerStats{Instant: &bitscope.Trace{
+		SampleRate:   v.SampleRate,
+		TriggerIndex: v.TriggerIndex,
+		Timestamp:    v.Timestamp,
+		Cause:        v.Cause,
+		Channel:      v.Channel,
+		Values:       make([]float64, len(vv)),
+		Unit:         "W",
+	}}
+	for k := range vv {
+		p.Instant.Values[k] = vv[k] * vi[k] * ratio
+	}
+
+	// Whole periods, between the first and last rising crossings
+	a, b := 0, len(vv)
+	lo, hi := extremes(vv)
+	if x := crossings(vv, (lo+hi)/2, (hi-lo)/10, true); len(x) >= 2 {
+		a, b = int(math.Round(x[0])), int(math.Round(x[len(x)-1]))
+	}
+
+	for k := a; k < b; k++ {
+		p.Real += p.Instant.Values[k]
+		p.Vrms += vv[k] * vv[k]
+		p.Irms += vi[k] * vi[k] * ratio * ratio
+	}
+	n := float64(b - a)
+	p.Real /= n
+	p.Vrms = math.Sqrt(p.Vrms / n)
+	p.Irms = math.Sqrt(p.Irms / n)
+	p.Apparent = p.Vrms * p.Irms
+	if p.Apparent != 0 {
+		p.Factor = p.Real / p.Apparent
+	}
+	return p, nil
+}
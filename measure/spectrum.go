@@ -0,0 +1,114 @@
+// For the license see the LICENSE file (BSD style)
+
+package measure
+
+import (
+	"math"
+	"math/cmplx"
+
+	"bitscope"
+)
+
+// Window is a window function applied to the samples before the FFT.
+type Window int
+
+const (
+	// No window (rectangular): the best resolution, but strong leakage
+	// unless the trace holds a whole number of periods
+	Rect Window = iota
+	Hann
+	Hamming
+	// The lowest leakage, and the widest peaks
+	Blackman
+)
+
+// lobe returns the half width of the main lobe of the window, in bins of
+// the DFT of the samples without padding.
+func (w Window) lobe() float64 {
+	switch w {
+	case Hann, Hamming:
+		return 2
+	case Blackman:
+		return 3
+	}
+	return 1
+}
+
+// weight returns the value of the window at sample i of n.
+func (w Window) weight(i, n int) float64 {
+	x := 2 * math.Pi * float64(i) / float64(n-1)
+	switch w {
+	case Hann:
+		return 0.5 - 0.5*math.Cos(x)
+	case Hamming:
+		return 0.54 - 0.46*math.Cos(x)
+	case Blackman:
+		return 0.42 - 0.5*math.Cos(x) + 0.08*math.Cos(2*x)
+	}
+	return 1
+}
+
+// Bins is the amplitude spectrum of a trace, from DC to half the sample
+// rate.
+type Bins struct {
+	// Frequency of each bin in Hz
+	Freq []float64
+	// RMS voltage of the component at each bin, and the same in dBV
+	// (dB relative to 1 V rms)
+	Vrms []float64
+	DBV  []float64
+	// Equivalent noise bandwidth of the window, in bins: the sum of the
+	// squares of Vrms over the bins of a component is its power times
+	// ENBW
+	ENBW float64
+
+	// Half width of the main lobe of the window, in bins
+	lobe int
+}
+
+// Spectrum computes the spectrum of a trace with the given window. The
+// samples are padded with zeros to a power of 2, so the bins may be
+// narrower than the sample rate divided by the number of samples; the
+// amplitudes are corrected for the window and the padding.
+func Spectrum(t *bitscope.Trace, w Window) (*Bins, error) {
+
+	if t.SampleRate == 0 {
+		return nil, ErrNoRate
+	}
+	v, err := volts(t)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(v)
+	x := make([]complex128, pow2(n))
+	sum, sum2 := 0.0, 0.0
+	for i, s := range v {
+		k := w.weight(i, n)
+		x[i] = complex(s*k, 0)
+		sum += k
+		sum2 += k * k
+	}
+	y := fft(x)
+
+	m := len(x)/2 + 1
+	sp := &Bins{
+		Freq: make([]float64, m),
+		Vrms: make([]float64, m),
+		DBV:  make([]float64, m),
+		ENBW: float64(len(x)) * sum2 / (sum * sum),
+		lobe: int(math.Ceil(w.lobe() * float64(len(x)) / float64(n))),
+	}
+	for k := range sp.Freq {
+		sp.Freq[k] = float64(k) * t.SampleRate / float64(len(x))
+
+		// Peak amplitude of a sine at bin k, then rms
+		a := cmplx.Abs(y[k]) / sum
+		if k > 0 && k < len(x)/2 {
+			a *= 2 / math.Sqrt2
+		}
+		sp.Vrms[k] = a
+		sp.DBV[k] = 20 * math.Log10(a)
+	}
+	return sp, nil
+}
@@ -0,0 +1,119 @@
+// For the license see the LICENSE file (BSD style)
+
+package measure
+
+import (
+	"errors"
+	"math"
+
+	"bitscope"
+)
+
+// Distribution counts the samples of a trace in bins of equal width.
+type Distribution struct {
+	// Lower edge of the first bin and upper edge of the last one
+	Min, Max float64
+	Counts   []int
+}
+
+// Center returns the value at the center of bin i.
+func (h *Distribution) Center(i int) float64 {
+	return h.Min + (float64(i)+0.5)*(h.Max-h.Min)/float64(len(h.Counts))
+}
+
+// Histogram returns the distribution of the samples of a trace, in the
+// given number of bins between its lowest and highest samples.
+func Histogram(t *bitscope.Trace, bins int) (*Distribution, error) {
+
+	if bins <= 0 {
+		return nil, errors.New("Invalid number of bins")
+	}
+	v, err := volts(t)
+	if err != nil {
+		return nil, err
+	}
+
+	lo, hi := extremes(v)
+	h := &Distribution{Min: lo, Max: hi, Counts: make([]int, bins)}
+	for _, x := range v {
+		i := bins - 1
+		if hi > lo {
+			i = int(float64(bins) * (x - lo) / (hi - lo))
+		}
+		if i == bins {
+			i--
+		}
+		h.Counts[i]++
+	}
+	return h, nil
+}
+
+// StdDev returns the standard deviation of the samples: the rms of the
+// signal without its mean, such as the noise on a DC level.
+func StdDev(t *bitscope.Trace) (float64, error) {
+	v, err := volts(t)
+	if err != nil {
+		return 0, err
+	}
+	m := mean(v)
+	s := 0.0
+	for _, x := range v {
+		s += (x - m) * (x - m)
+	}
+	return math.Sqrt(s / float64(len(v))), nil
+}
+
+// Stats accumulates the mean and standard deviation of each sample index
+// over several acquisitions of a repetitive signal, triggered at the same
+// point: the mean shows the signal without its noise, and the standard
+// deviation where the noise or jitter is. The zero value is ready to use.
+type Stats struct {
+	n    int
+	mean []float64
+	m2   []float64
+}
+
+// Add adds a trace to the statistics. All the traces must have the same
+// length.
+func (s *Stats) Add(t *bitscope.Trace) error {
+
+	v, err := volts(t)
+	if err != nil {
+		return err
+	}
+	if s.n == 0 {
+		s.mean = make([]float64, len(v))
+		s.m2 = make([]float64, len(v))
+	}
+	if len(v) != len(s.mean) {
+		return errors.New("Traces of different length")
+	}
+
+	// Welford's algorithm
+	s.n++
+	for i, x := range v {
+		d := x - s.mean[i]
+		s.mean[i] += d / float64(s.n)
+		s.m2[i] += d * (x - s.mean[i])
+	}
+	return nil
+}
+
+// N returns the number of traces added.
+func (s *Stats) N() int {
+	return s.n
+}
+
+// Mean returns the mean of each sample.
+func (s *Stats) Mean() []float64 {
+	return append([]float64(nil), s.mean...)
+}
+
+// StdDev returns the standard deviation of each sample (over N, not N-1).
+func (s *Stats) StdDev() []float64 {
+	d := make([]float64, len(s.m2))
+	for i, m2 := range s.m2 {
+		d[i] = math.Sqrt(m2 / float64(s.n))
+	}
+	return d
+}
@@ -0,0 +1,84 @@
+// For the license see the LICENSE file (BSD style)
+
+package measure
+
+import (
+	"errors"
+	"math"
+
+	"bitscope"
+)
+
+// ErrNotSettled is returned when a step response does not stay within the
+// tolerance before the end of the trace.
+var ErrNotSettled = errors.New("Step response does not settle")
+
+// Step describes the response to a step, as captured by a trace that
+// starts settled at the initial level and ends settled at the final one.
+type Step struct {
+	// Settled levels before and after the step, in volts
+	Initial, Final float64
+	// Time of the crossing of the level halfway between them, in seconds
+	Edge float64
+	// Largest excursions beyond the final level, in the direction of the
+	// step and back once it has been reached, as a percentage of the step
+	Overshoot, Undershoot float64
+	// Time from the edge until the signal stays within the tolerance
+	// around the final level, in seconds
+	Settling float64
+}
+
+// StepResponse finds the step in a trace and measures its overshoot,
+// undershoot and settling time, with the tolerance as a fraction of the
+// step (0.02 for 2%). The initial and final levels are the mean of the
+// first and last tenth of the trace, and the edge is the first crossing
+// of the middle level, so the trace should hold one step with room on
+// both sides.
+func StepResponse(t *bitscope.Trace, tol float64) (*Step, error) {
+
+	if t.SampleRate == 0 {
+		return nil, ErrNoRate
+	}
+	v, err := volts(t)
+	if err != nil {
+		return nil, err
+	}
+	n := len(v) / 10
+	if n == 0 {
+		return nil, ErrNoEdge
+	}
+
+	s := &Step{Initial: mean(v[:n]), Final: mean(v[len(v)-n:])}
+	a := s.Final - s.Initial
+	// Less than one code is no step
+	if a == 0 || math.Abs(a) < bitscope.CodeToVolts(1, t.Range)-bitscope.CodeToVolts(0, t.Range) {
+		return nil, ErrNoEdge
+	}
+
+	x := crossings(v, (s.Initial+s.Final)/2, math.Abs(a)/50, a > 0)
+	if len(x) == 0 {
+		return nil, ErrNoEdge
+	}
+	s.Edge = x[0] / t.SampleRate
+
+	// Deviation from the final level, relative to the step
+	reached, last := false, -1
+	for i := int(x[0]); i < len(v); i++ {
+		d := (v[i] - s.Final) / a
+		if d >= 0 {
+			reached = true
+		}
+		s.Overshoot = math.Max(s.Overshoot, d*100)
+		if reached {
+			s.Undershoot = math.Max(s.Undershoot, -d*100)
+		}
+		if math.Abs(d) > tol {
+			last = i
+		}
+	}
+	if last == len(v)-1 {
+		return s, ErrNotSettled
+	}
+	s.Settling = math.Max(0, float64(last+1)/t.SampleRate-s.Edge)
+	return s, nil
+}
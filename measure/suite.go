@@ -0,0 +1,95 @@
+// For the license see the LICENSE file (BSD style)
+
+package measure
+
+import (
+	"math"
+
+	"bitscope"
+)
+
+// Set selects groups of measurements for Measure.
+type Set int
+
+const (
+	// Min, Max, Vpp, Mean, Vrms and StdDev
+	Voltage Set = 1 << iota
+	// Base, Top and Amplitude
+	Level
+	// Period, Frequency, RiseTime, FallTime, the pulse widths and
+	// DutyCycle; they imply Level
+	Timing
+
+	All = Voltage | Level | Timing
+)
+
+// Results holds the measurements of a trace. Those that were not selected,
+// or cannot be made on the trace (a period without edges, for example),
+// are NaN.
+type Results struct {
+	Min, Max, Vpp     float64
+	Mean, Vrms        float64
+	StdDev            float64
+	Base, Top         float64
+	Amplitude         float64
+	Period, Frequency float64
+	RiseTime          float64
+	FallTime          float64
+	PositiveWidth     float64
+	NegativeWidth     float64
+	DutyCycle         float64
+}
+
+// Measure makes a set of measurements on a trace at once, as the
+// functions of the same names with DefaultRefs, but sharing the work: the
+// voltage measurements take a single pass over the samples.
+func Measure(t *bitscope.Trace, s Set) (*Results, error) {
+
+	v, err := volts(t)
+	if err != nil {
+		return nil, err
+	}
+
+	nan := math.NaN()
+	r := &Results{nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan, nan}
+
+	if s&Voltage != 0 {
+		lo, hi, sum, sum2 := v[0], v[0], 0.0, 0.0
+		for _, x := range v {
+			lo, hi = math.Min(lo, x), math.Max(hi, x)
+			sum += x
+			sum2 += x * x
+		}
+		n := float64(len(v))
+		r.Min, r.Max, r.Vpp = lo, hi, hi-lo
+		r.Mean, r.Vrms = sum/n, math.Sqrt(sum2/n)
+		r.StdDev = math.Sqrt(math.Max(0, sum2/n-r.Mean*r.Mean))
+	}
+
+	if s&(Level|Timing) != 0 {
+		r.Base, r.Top, _ = Levels(t)
+		r.Amplitude = r.Top - r.Base
+	}
+
+	if s&Timing != 0 && t.SampleRate != 0 {
+		if p, _, err := Period(t); err == nil {
+			r.Period, r.Frequency = p, 1/p
+		}
+		e, err := newEdges(t, DefaultRefs)
+		if err == nil {
+			r.RiseTime = orNaN(e.transitions(e.low, true, e.high, true))
+			r.FallTime = orNaN(e.transitions(e.high, false, e.low, false))
+			r.PositiveWidth = orNaN(e.transitions(e.mid, true, e.mid, false))
+			r.NegativeWidth = orNaN(e.transitions(e.mid, false, e.mid, true))
+			r.DutyCycle = r.PositiveWidth / (r.PositiveWidth + r.NegativeWidth)
+		}
+	}
+	return r, nil
+}
+
+func orNaN(x float64, err error) float64 {
+	if err != nil {
+		return math.NaN()
+	}
+	return x
+}
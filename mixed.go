@@ -0,0 +1,45 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import "context"
+
+// MixedTrace holds the analog samples of CHA and the time aligned samples
+// of the logic inputs of a mixed-signal trace: Analog[i] and Logic.Raw[i]
+// were taken at the same time.
+type MixedTrace struct {
+	// Sample rate of each stream, in samples per second (0 if unknown)
+	Rate   float64
+	Analog []byte
+	Logic  *LogicCapture
+}
+
+// TraceMixed is Trace for CHA and the logic inputs at once. Analog and
+// logic samples are taken alternately, so that each stream gets half the
+// sample rate; pre and post are numbers of samples per stream.
+func (bs *Scope) TraceMixed(pre, post, delay uint) ([]byte, error) {
+	return bs.TraceMixedContext(context.Background(), pre, post, delay)
+}
+
+// TraceMixedContext is TraceMixed with a context (see TraceContext).
+func (bs *Scope) TraceMixedContext(ctx context.Context, pre, post, delay uint) ([]byte, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.traceCapture(ctx, captureMixed, 2*pre, 2*post, delay)
+}
+
+// DumpMixed reads size samples of each stream after a TraceMixed.
+func (bs *Scope) DumpMixed(size uint) (*MixedTrace, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	d, err := bs.dump(context.Background(), 2*size)
+
+	rate := bs.sampleRate() / 2
+	a, l := deinterleave(d)
+
+	return &MixedTrace{Rate: rate, Analog: a, Logic: newLogicCapture(l, rate)}, err
+}
@@ -0,0 +1,18 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import "bitscope/sim"
+
+// Mock is an in-memory Transport that emulates the VM of a BitScope, so
+// that programs using this package can be tested without an instrument:
+//
+//	bs, err := bitscope.New(bitscope.NewMock("bs10"))
+//
+// It is the simulator of package sim; see there for what it implements.
+type Mock = sim.VM
+
+// NewMock returns a mock transport for the given model ("bs10" or "bs05").
+func NewMock(model string) *Mock {
+	return sim.New(model)
+}
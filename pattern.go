@@ -0,0 +1,79 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"errors"
+	"strings"
+)
+
+// TriggerPattern sets a logic trigger from a pattern string with one
+// character per logic input, input 7 first: '1' and '0' are the levels to
+// match and 'X' a don't care. One input can be given as 'R' (rising) or 'F'
+// (falling) instead, which sets edge mode. The VM has no edge detector per
+// input: that input is matched at its new level ('1' for 'R', '0' for 'F'),
+// and the trigger fires when the whole pattern starts to match. This is
+// when the input changes while the other inputs match, but also when
+// another input completes the match after the change. Spaces and
+// underscores are ignored, so "1X0X XX10" and "1X0XXX10" are the same
+// pattern.
+//
+// The trigger source is set to TrigLogic.
+func (bs *Scope) TriggerPattern(p string) error {
+
+	level, mask, edge, err := parsePattern(p)
+	if err != nil {
+		return err
+	}
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	mode := bs.spock &^ 0x35
+	if edge {
+		mode |= 0x20
+	}
+
+	if err = bs.triggerLogic(level, mask); err != nil {
+		return err
+	}
+	if err = bs.triggerMode(mode); err != nil {
+		return err
+	}
+	bs.trigSource = TrigLogic
+	return nil
+}
+
+// parsePattern returns the TriggerLogic and TriggerMask values of a
+// pattern (see TriggerPattern), and whether it has an edge.
+func parsePattern(p string) (level, mask uint, edge bool, err error) {
+
+	p = strings.NewReplacer(" ", "", "_", "").Replace(strings.ToUpper(p))
+	if len(p) != 8 {
+		return 0, 0, false, errors.New("Trigger pattern must have 8 inputs")
+	}
+
+	for i, c := range p {
+		bit := uint(1) << (7 - i)
+		switch c {
+		case '1':
+			level |= bit
+		case '0':
+		case 'X':
+			mask |= bit
+		case 'R', 'F':
+			if edge {
+				return 0, 0, false, errors.New("Only one edge allowed in trigger pattern")
+			}
+			edge = true
+			// The trigger condition becomes true when the input reaches
+			// its new level.
+			if c == 'R' {
+				level |= bit
+			}
+		default:
+			return 0, 0, false, errors.New("Invalid character in trigger pattern")
+		}
+	}
+	return level, mask, edge, nil
+}
@@ -0,0 +1,124 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+)
+
+// pending is a command that has been written and waits for its response.
+type pending struct {
+	cmd []byte
+	f   frame
+	t   time.Time
+}
+
+// pipeline sends independent commands without waiting for the response to
+// each one: a writer goroutine writes the commands and queues them, and a
+// reader goroutine matches the incoming responses with the queued
+// commands, in order. It returns the first error found.
+//
+// This cuts the setup time of a sequence of register writes, which would
+// otherwise cost a round trip each.
+func (bs *Scope) pipeline(ctx context.Context, cmds ...[]byte) error {
+
+	if len(cmds) == 0 {
+		return nil
+	}
+	if err := bs.checkArmed(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	queue := make(chan pending, len(cmds))
+	werr := make(chan error, 1)
+	rerr := make(chan error, 1)
+
+	// Writer
+	go func() {
+		defer close(queue)
+
+		for _, cmd := range cmds {
+
+			if bs.logLevel >= LogCommands {
+				bs.logger.Printf("bitscope: TX %q", cmd)
+			}
+
+			t := time.Now()
+			n, err := bs.tty.Write(cmd)
+			if err == nil && n != len(cmd) {
+				err = errors.New("Not all bytes were written")
+			}
+			if err != nil {
+				werr <- err
+				return
+			}
+
+			queue <- pending{cmd, frame{}, t}
+		}
+		werr <- nil
+	}()
+
+	// Reader
+	go func() {
+		var acc []byte
+		r := make([]byte, 256)
+
+		for p := range queue {
+			for {
+				n := p.f.size(p.cmd, acc)
+				if n >= 0 {
+					if bs.logLevel >= LogRaw {
+						bs.logger.Printf("bitscope: RX %q", acc[:n])
+					}
+					if bs.ptrace != nil {
+						bs.ptrace.log(p.t, p.cmd, acc[:n], nil)
+					}
+					if !bytes.Equal(acc[:len(p.cmd)], p.cmd) {
+						rerr <- ErrOutOfSync
+						return
+					}
+					acc = acc[n:]
+					break
+				}
+
+				if ctx.Err() != nil {
+					rerr <- ErrTimeout
+					return
+				}
+
+				bs.tty.SetDeadline(pollDeadline(ctx))
+
+				n, err := bs.tty.Read(r)
+				if err != nil {
+					rerr <- err
+					return
+				}
+				acc = append(acc, r[:n]...)
+			}
+		}
+		rerr <- nil
+	}()
+
+	// The reader has stopped using the transport
+	err := <-rerr
+	bs.tty.SetDeadline(time.Time{})
+	if e := <-werr; err == nil {
+		err = e
+	}
+
+	switch err {
+	case nil:
+	case ErrTimeout, ErrOutOfSync:
+		bs.resync()
+	default:
+		err = bs.lost(err)
+	}
+
+	return err
+}
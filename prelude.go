@@ -0,0 +1,15 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+// SetPrelude sets the value to which the sample buffer is initialized at
+// the start of each trace (default 0). Samples that the trace does not
+// write keep it, so that a value that the signal cannot take, or mid-scale
+// (0x80), makes them easy to recognize in dumps.
+func (bs *Scope) SetPrelude(v uint) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	bs.prelude = v
+}
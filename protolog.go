@@ -0,0 +1,134 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// ProtocolEntry is a line of the protocol trace written by SetProtocolTrace:
+// a command sent to the VM and its response.
+type ProtocolEntry struct {
+	// Time at which the command was written
+	Time time.Time `json:"time"`
+	// Time until the response was complete, in microseconds
+	Micros int64 `json:"us"`
+	// The command, as text and in hex
+	Cmd    string `json:"cmd"`
+	CmdHex string `json:"cmd_hex"`
+	// The register writes contained in the command
+	Writes []RegWrite `json:"writes,omitempty"`
+	// The response, echo included, in hex
+	RespHex string `json:"resp_hex"`
+	// The error of the exchange, if any
+	Err string `json:"err,omitempty"`
+}
+
+// RegWrite is the write of a value to a register.
+type RegWrite struct {
+	Reg   Register `json:"reg"`
+	Value uint     `json:"value"`
+}
+
+// protocolTrace writes ProtocolEntries as JSON lines.
+type protocolTrace struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// SetProtocolTrace logs every command sent to the BitScope and its response
+// to w, as one JSON object per line (see ProtocolEntry). Register writes are
+// decoded. A nil writer disables the trace.
+func (bs *Scope) SetProtocolTrace(w io.Writer) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if w == nil {
+		bs.ptrace = nil
+		return
+	}
+	bs.ptrace = &protocolTrace{enc: json.NewEncoder(w)}
+}
+
+func (p *protocolTrace) log(t time.Time, cmd, resp []byte, err error) {
+
+	e := ProtocolEntry{
+		Time:    t,
+		Micros:  int64(time.Since(t) / time.Microsecond),
+		Cmd:     string(cmd),
+		CmdHex:  hex.EncodeToString(cmd),
+		Writes:  decodeWrites(cmd),
+		RespHex: hex.EncodeToString(resp),
+	}
+	if err != nil {
+		e.Err = err.Error()
+	}
+
+	// The pipeline reader and exchange can log concurrently
+	p.mu.Lock()
+	p.enc.Encode(&e)
+	p.mu.Unlock()
+}
+
+// decodeWrites returns the register writes contained in a VM command.
+// Consecutive byte writes to a register wider than one byte are merged.
+func decodeWrites(cmd []byte) []RegWrite {
+
+	var single []RegWrite
+	var addr, value uint
+
+	for _, c := range cmd {
+		switch {
+		case c >= '0' && c <= '9':
+			value = value<<4 | uint(c-'0')
+		case c >= 'a' && c <= 'f':
+			value = value<<4 | uint(c-'a'+10)
+		case c == '[':
+			value = 0
+		case c == '@':
+			addr = value & 0xff
+			value = 0
+		case c == 's', c == 'z':
+			single = append(single, RegWrite{Register(addr), value & 0xff})
+			value = 0
+			if c == 'z' {
+				addr = (addr + 1) & 0xff
+			}
+		case c == 'n':
+			addr = (addr + 1) & 0xff
+		}
+	}
+
+	var w []RegWrite
+
+	for i := 0; i < len(single); {
+
+		r := single[i].Reg
+		n := r.Size()
+
+		// The single of a wide register must be consecutive
+		if i+n > len(single) {
+			n = 1
+		}
+		for k := 1; k < n; k++ {
+			if single[i+k].Reg != r+Register(k) {
+				n = 1
+				break
+			}
+		}
+
+		var v uint
+		for k := n - 1; k >= 0; k-- {
+			v = v<<8 | single[i+k].Value
+		}
+		w = append(w, RegWrite{r, v})
+		i += n
+	}
+
+	return w
+}
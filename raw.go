@@ -0,0 +1,47 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"time"
+)
+
+// ResponseSpec describes the response expected from raw VM commands, after
+// their echo: a number of binary data bytes, followed by a number of
+// non-empty CR terminated lines.
+type ResponseSpec struct {
+	Data  int
+	Lines int
+	// Time to wait for the whole response (default: one second plus the
+	// transfer time of the data)
+	Timeout time.Duration
+}
+
+// Raw sends arbitrary VM commands and returns their response, without the
+// echo of the commands. It is an escape hatch for driving VM features that
+// this package does not cover; the commands may leave the BitScope in a
+// state that the other methods do not expect.
+//
+// Raw commands are not retried and not repeated after a reconnection.
+func (bs *Scope) Raw(cmd []byte, expect ResponseSpec) ([]byte, error) {
+	return bs.RawContext(context.Background(), cmd, expect)
+}
+
+// RawContext is Raw with a context.
+func (bs *Scope) RawContext(ctx context.Context, cmd []byte, expect ResponseSpec) ([]byte, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	timeout := expect.Timeout
+	if timeout == 0 {
+		timeout = bs.transferTimeout(len(cmd) + expect.Data)
+	}
+
+	r, err := bs.exchange(ctx, cmd, frame{data: expect.Data, lines: expect.Lines}, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return r.raw[len(r.echo):], nil
+}
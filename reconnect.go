@@ -0,0 +1,103 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"errors"
+	"io"
+	"syscall"
+	"time"
+)
+
+// ErrReconnected is returned by a command that failed because the
+// connection was lost, after the BitScope was re-opened and its last
+// configuration restored. The command itself has to be repeated.
+var ErrReconnected = errors.New("BitScope reconnected, configuration restored")
+
+// Reconnection attempts: the USB device needs some time to reappear.
+const (
+	reconnectTries = 20
+	reconnectDelay = 500 * time.Millisecond
+)
+
+// setting is a configuration command, remembered in order to restore the
+// configuration after a reconnection.
+type setting struct {
+	key string
+	cmd []byte
+}
+
+// set sends a configuration command and remembers it under the given key,
+// replacing the previous command with the same key.
+func (bs *Scope) set(key string, b []byte) ([]byte, error) {
+	if err := bs.checkArmed(); err != nil {
+		return nil, err
+	}
+	bs.remember(key, b)
+	return bs.call(b)
+}
+
+// remember stores a configuration command under the given key, to be sent
+// again after a reconnection.
+func (bs *Scope) remember(key string, b []byte) {
+
+	c := append([]byte(nil), b...)
+
+	for i := range bs.config {
+		if bs.config[i].key == key {
+			bs.config[i].cmd = c
+			return
+		}
+	}
+	bs.config = append(bs.config, setting{key, c})
+}
+
+// lost checks whether err means that the device is gone. If so, and the
+// Scope was opened with the Reconnect option, the device is re-opened,
+// its ID verified and the configuration restored, and ErrReconnected is
+// returned. Otherwise err is returned unchanged.
+func (bs *Scope) lost(err error) error {
+
+	if err == nil || bs.reopen == nil || bs.reconnecting || !disconnected(err) {
+		return err
+	}
+
+	bs.reconnecting = true
+	defer func() { bs.reconnecting = false }()
+
+	bs.tty.Close()
+
+	for i := 0; i < reconnectTries; i++ {
+
+		time.Sleep(reconnectDelay)
+
+		tty, e := bs.reopen()
+		if e != nil {
+			continue
+		}
+
+		bs.tty = tty
+		if bs.id(context.Background()) != bs.ID {
+			tty.Close()
+			continue
+		}
+
+		for _, s := range bs.config {
+			bs.call(s.cmd)
+		}
+
+		return ErrReconnected
+	}
+
+	return err
+}
+
+// disconnected returns true for the errors returned by a tty whose device
+// has been unplugged.
+func disconnected(err error) bool {
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.EIO) ||
+		errors.Is(err, syscall.ENXIO) ||
+		errors.Is(err, syscall.ENODEV)
+}
@@ -0,0 +1,181 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder is a Transport that passes all the traffic to another transport
+// and writes it to a log, one line per transfer:
+//
+//	<microseconds since start> <W|R> <hex bytes>
+//
+// W lines are bytes written to the instrument, R lines bytes read from it.
+// The log can be fed back with Replay.
+type Recorder struct {
+	t     Transport
+	w     io.Writer
+	start time.Time
+	mu    sync.Mutex
+}
+
+// Record returns a Transport that logs the traffic of t to w.
+func Record(t Transport, w io.Writer) *Recorder {
+	return &Recorder{t: t, w: w, start: time.Now()}
+}
+
+func (r *Recorder) log(dir byte, b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	r.mu.Lock()
+	fmt.Fprintf(r.w, "%d %c %x\n", time.Since(r.start)/time.Microsecond, dir, b)
+	r.mu.Unlock()
+}
+
+func (r *Recorder) Write(b []byte) (int, error) {
+	n, err := r.t.Write(b)
+	r.log('W', b[:n])
+	return n, err
+}
+
+func (r *Recorder) Read(b []byte) (int, error) {
+	n, err := r.t.Read(b)
+	r.log('R', b[:n])
+	return n, err
+}
+
+func (r *Recorder) SetDeadline(t time.Time) error {
+	return r.t.SetDeadline(t)
+}
+
+func (r *Recorder) Close() error {
+	return r.t.Close()
+}
+
+// record is one line of a recorded session.
+type record struct {
+	write bool
+	data  []byte
+}
+
+// Replayer is a Transport that plays back a session recorded with Record.
+// Written bytes are checked against the recording, and reads return the
+// recorded responses once the commands preceding them have been written.
+type Replayer struct {
+	recs []record
+	mu   sync.Mutex
+}
+
+// Replay reads a recorded session.
+func Replay(rd io.Reader) (*Replayer, error) {
+
+	var recs []record
+
+	sc := bufio.NewScanner(rd)
+	for line := 1; sc.Scan(); line++ {
+
+		f := strings.Fields(sc.Text())
+		if len(f) == 0 {
+			continue
+		}
+
+		if len(f) != 3 || (f[1] != "W" && f[1] != "R") {
+			return nil, errors.New("Replay: syntax error at line " + strconv.Itoa(line))
+		}
+
+		b, err := hex.DecodeString(f[2])
+		if err != nil {
+			return nil, errors.New("Replay: bad data at line " + strconv.Itoa(line))
+		}
+
+		// Join consecutive transfers in the same direction
+		if n := len(recs); n > 0 && recs[n-1].write == (f[1] == "W") {
+			recs[n-1].data = append(recs[n-1].data, b...)
+		} else {
+			recs = append(recs, record{f[1] == "W", b})
+		}
+	}
+
+	return &Replayer{recs: recs}, sc.Err()
+}
+
+// Write returns an error if b is not what was written at this point of the
+// recorded session.
+func (r *Replayer) Write(b []byte) (int, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for n < len(b) {
+
+		if len(r.recs) == 0 || !r.recs[0].write {
+			return n, errors.New("Replay: unexpected command " + strconv.Quote(string(b[n:])))
+		}
+
+		rec := &r.recs[0]
+		m := len(b) - n
+		if m > len(rec.data) {
+			m = len(rec.data)
+		}
+
+		if !bytes.Equal(b[n:n+m], rec.data[:m]) {
+			return n, errors.New("Replay: command " + strconv.Quote(string(b[n:])) +
+				" differs from recording " + strconv.Quote(string(rec.data)))
+		}
+
+		n += m
+		rec.data = rec.data[m:]
+		if len(rec.data) == 0 {
+			r.recs = r.recs[1:]
+		}
+	}
+
+	return n, nil
+}
+
+// Read returns recorded response bytes. If the recording expects a command
+// first, it returns 0 bytes, as a serial port whose read timeout expired.
+// At the end of the recording, io.EOF is returned.
+func (r *Replayer) Read(b []byte) (int, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.recs) == 0 {
+		return 0, io.EOF
+	}
+
+	rec := &r.recs[0]
+	if rec.write {
+		return 0, nil
+	}
+
+	n := copy(b, rec.data)
+	rec.data = rec.data[n:]
+	if len(rec.data) == 0 {
+		r.recs = r.recs[1:]
+	}
+
+	return n, nil
+}
+
+// SetDeadline does nothing: reads never block.
+func (r *Replayer) SetDeadline(t time.Time) error {
+	return nil
+}
+
+func (r *Replayer) Close() error {
+	return nil
+}
@@ -0,0 +1,260 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// Register is the address of a register of the BitScope VM. Registers wider
+// than one byte occupy consecutive addresses, least significant byte first.
+type Register uint8
+
+// VM registers of the BS05 and BS10.
+const (
+	RegTriggerLogic  Register = 0x05 // Trigger logic, one bit per logic channel
+	RegTriggerMask   Register = 0x06 // Trigger logic mask (bits to ignore)
+	RegSpockOption   Register = 0x07 // Trigger options (see TriggerMode)
+	RegSampleAddress Register = 0x08 // Buffer address, 24 bits
+	RegSampleCounter Register = 0x0b // Sample counter, 24 bits
+	RegClockScale    Register = 0x14 // Clock prescaler
+	RegDumpRepeat    Register = 0x16 // Dump repeat count
+	RegDumpSend      Register = 0x18 // Dump send count
+	RegDumpSkip      Register = 0x1a // Dump skip count
+	RegDumpCount     Register = 0x1c // Number of bytes to dump
+	RegDumpMode      Register = 0x1e // Dump mode
+	RegTraceMode     Register = 0x21 // Trace mode
+	RegTraceDelay    Register = 0x22 // Delay after the trigger, 32 bits
+	RegTraceIntro    Register = 0x26 // Pre-trigger samples
+	RegTraceOutro    Register = 0x2a // Post-trigger samples
+	RegTimeout       Register = 0x2c // Trigger timeout, ticks of 6.4 us
+	RegClockTicks    Register = 0x2e // Clock divisor (master clock ticks)
+	RegDumpChan      Register = 0x30 // Channel to dump
+	RegBufferMode    Register = 0x31 // Buffer mode
+	RegTriggerIntro  Register = 0x32 // Trigger hold-off
+	RegTriggerOutro  Register = 0x34 // Trigger hold-on
+	RegAnalogEnable  Register = 0x37 // Analog channel enable bits
+	RegDigitalEnable Register = 0x38 // Logic channel enable bits
+	RegPrelude       Register = 0x3a // Buffer default value
+	RegTriggerValue  Register = 0x44 // Digital trigger level
+	RegAwgCmd        Register = 0x46 // Waveform generator command
+	RegAwgMode       Register = 0x47 // Waveform generator mode
+	RegAwgOption     Register = 0x48 // Waveform generator options
+	RegAwgSize       Register = 0x4a // Waveform size
+	RegAwgIndex      Register = 0x4c // Waveform index
+	RegAwgAddress    Register = 0x4e // Waveform buffer address
+	RegAwgClock      Register = 0x50 // Waveform sample clock
+	RegAwgModulo     Register = 0x52 // Waveform table modulo
+	RegAwgLevel      Register = 0x54 // Waveform output level
+	RegAwgOffset     Register = 0x56 // Waveform output offset
+	RegAwgMask       Register = 0x58 // Waveform translation mask
+	RegAwgRatio      Register = 0x5a // Waveform translation ratio, 32 bits
+	RegAwgMark       Register = 0x5e // Waveform mark (high) time
+	RegAwgSpace      Register = 0x60 // Waveform space (low) time
+	RegConverterLo   Register = 0x64 // ADC range low calibration
+	RegConverterHi   Register = 0x66 // ADC range high calibration
+	RegTriggerLevel  Register = 0x68 // Analog trigger level
+	RegTriggerUpper  Register = 0x6a // Upper analog trigger level (window)
+	RegLogicControl  Register = 0x74 // Logic port control
+	RegLogicOutput   Register = 0x75 // Logic port output levels
+	RegAwgRest       Register = 0x78 // Waveform generator rest level
+	RegKitchenSinkA  Register = 0x7b // Comparator enables
+	RegKitchenSinkB  Register = 0x7c // Analog filter and generator enables
+	RegClockRise     Register = 0x82 // Clock generator rise time
+	RegClockFall     Register = 0x84 // Clock generator fall time
+	RegClockControl  Register = 0x86 // Clock generator control
+	RegLedRed        Register = 0xfa // Red LED intensity
+	RegLedGreen      Register = 0xfb // Green LED intensity
+	RegLedYellow     Register = 0xfc // Yellow LED intensity
+)
+
+// regNames holds the names of the registers, without the Reg prefix.
+var regNames = map[Register]string{
+	RegTriggerLogic:  "TriggerLogic",
+	RegTriggerMask:   "TriggerMask",
+	RegSpockOption:   "SpockOption",
+	RegSampleAddress: "SampleAddress",
+	RegSampleCounter: "SampleCounter",
+	RegClockScale:    "ClockScale",
+	RegDumpRepeat:    "DumpRepeat",
+	RegDumpSend:      "DumpSend",
+	RegDumpSkip:      "DumpSkip",
+	RegDumpCount:     "DumpCount",
+	RegDumpMode:      "DumpMode",
+	RegTraceMode:     "TraceMode",
+	RegTraceDelay:    "TraceDelay",
+	RegTraceIntro:    "TraceIntro",
+	RegTraceOutro:    "TraceOutro",
+	RegTimeout:       "Timeout",
+	RegClockTicks:    "ClockTicks",
+	RegDumpChan:      "DumpChan",
+	RegBufferMode:    "BufferMode",
+	RegTriggerIntro:  "TriggerIntro",
+	RegTriggerOutro:  "TriggerOutro",
+	RegAnalogEnable:  "AnalogEnable",
+	RegDigitalEnable: "DigitalEnable",
+	RegPrelude:       "Prelude",
+	RegTriggerValue:  "TriggerValue",
+	RegAwgCmd:        "AwgCmd",
+	RegAwgMode:       "AwgMode",
+	RegAwgOption:     "AwgOption",
+	RegAwgSize:       "AwgSize",
+	RegAwgIndex:      "AwgIndex",
+	RegAwgAddress:    "AwgAddress",
+	RegAwgClock:      "AwgClock",
+	RegAwgModulo:     "AwgModulo",
+	RegAwgLevel:      "AwgLevel",
+	RegAwgOffset:     "AwgOffset",
+	RegAwgMask:       "AwgMask",
+	RegAwgRatio:      "AwgRatio",
+	RegAwgMark:       "AwgMark",
+	RegAwgSpace:      "AwgSpace",
+	RegConverterLo:   "ConverterLo",
+	RegConverterHi:   "ConverterHi",
+	RegTriggerLevel:  "TriggerLevel",
+	RegTriggerUpper:  "TriggerUpper",
+	RegLogicControl:  "LogicControl",
+	RegLogicOutput:   "LogicOutput",
+	RegAwgRest:       "AwgRest",
+	RegKitchenSinkA:  "KitchenSinkA",
+	RegKitchenSinkB:  "KitchenSinkB",
+	RegClockRise:     "ClockRise",
+	RegClockFall:     "ClockFall",
+	RegClockControl:  "ClockControl",
+	RegLedRed:        "LedRed",
+	RegLedGreen:      "LedGreen",
+	RegLedYellow:     "LedYellow",
+}
+
+// regSize holds the width in bytes of the registers wider than one byte.
+var regSize = map[Register]int{
+	RegSampleAddress: 3,
+	RegSampleCounter: 3,
+	RegClockScale:    2,
+	RegDumpRepeat:    2,
+	RegDumpSend:      2,
+	RegDumpSkip:      2,
+	RegDumpCount:     2,
+	RegTraceDelay:    4,
+	RegTraceIntro:    2,
+	RegTraceOutro:    2,
+	RegTimeout:       2,
+	RegClockTicks:    2,
+	RegTriggerIntro:  2,
+	RegTriggerOutro:  2,
+	RegPrelude:       2,
+	RegTriggerValue:  2,
+	RegAwgSize:       2,
+	RegAwgIndex:      2,
+	RegAwgAddress:    2,
+	RegAwgClock:      2,
+	RegAwgModulo:     2,
+	RegAwgLevel:      2,
+	RegAwgOffset:     2,
+	RegAwgMask:       2,
+	RegAwgRatio:      4,
+	RegAwgMark:       2,
+	RegAwgSpace:      2,
+	RegConverterLo:   2,
+	RegConverterHi:   2,
+	RegTriggerLevel:  2,
+	RegTriggerUpper:  2,
+	RegClockRise:     2,
+	RegClockFall:     2,
+}
+
+// Size returns the width of the register in bytes.
+func (r Register) Size() int {
+	if n, ok := regSize[r]; ok {
+		return n
+	}
+	return 1
+}
+
+// String returns the name of the register, or its address in hex.
+func (r Register) String() string {
+	if s, ok := regNames[r]; ok {
+		return s
+	}
+	return "0x" + string(hexByte(uint(r)))
+}
+
+// WriteReg writes a value to a register, with the width given by its Size.
+// The write is repeated after a reconnection.
+func (bs *Scope) WriteReg(r Register, value uint) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	_, err := bs.set("reg"+string(hexByte(uint(r))), reg(r, value))
+	return err
+}
+
+// ReadReg reads the value of a register, with the width given by its Size.
+func (bs *Scope) ReadReg(r Register) (uint, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.readReg(context.Background(), r)
+}
+
+// readReg reads a register one byte at a time with the peek command 'p',
+// which returns the byte at the current address as a hex number on a line
+// of its own. 'n' increments the address, so the reads are not retried.
+func (bs *Scope) readReg(ctx context.Context, r Register) (uint, error) {
+
+	cmd := []byte("00@p")
+	hex1(uint(r), cmd, 0)
+
+	var v uint
+	for i := 0; i < r.Size(); i++ {
+
+		res, err := bs.exchange(ctx, cmd, frame{lines: 1}, callTimeout)
+		if err != nil {
+			return 0, err
+		}
+
+		b, err := strconv.ParseUint(res.lines[0], 16, 8)
+		if err != nil {
+			return 0, errors.New("Invalid register value " + strconv.Quote(res.lines[0]))
+		}
+		v |= uint(b) << (8 * uint(i))
+
+		// Next byte
+		cmd = []byte("np")
+	}
+	return v, nil
+}
+
+// DumpRegisters reads all the named registers and returns their values.
+func (bs *Scope) DumpRegisters() (map[Register]uint, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	m := make(map[Register]uint, len(regNames))
+
+	for r := range regNames {
+		v, err := bs.readReg(context.Background(), r)
+		if err != nil {
+			return m, err
+		}
+		m[r] = v
+	}
+	return m, nil
+}
+
+// reg returns the command that writes value to register r.
+func reg(r Register, value uint) []byte {
+	return regCmd(uint(r), value, r.Size())
+}
+
+// hexByte returns n&0xff as two hex digits.
+func hexByte(n uint) []byte {
+	b := []byte("00")
+	hex1(n, b, 0)
+	return b
+}
@@ -0,0 +1,85 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"time"
+)
+
+// TraceConfig holds the parameters of a trace (see Trace).
+type TraceConfig struct {
+	Pre, Post, Delay uint
+	// Number of samples dumped after each trace (default: Pre+Post)
+	Size uint
+}
+
+// TraceRepeat captures n traces (0: no limit) and calls handler with the
+// data of each of them, until it returns false. The registers are set up
+// once; between traces only the buffer address is reset and the trigger
+// re-armed, so that the next capture starts as soon as possible.
+//
+// The Scope is locked during the whole loop.
+func (bs *Scope) TraceRepeat(cfg TraceConfig, n int, handler func(data []byte) bool) error {
+	return bs.TraceRepeatContext(context.Background(), cfg, n, handler)
+}
+
+// TraceRepeatContext is TraceRepeat with a context, which ends the loop
+// when done (see TraceContext).
+func (bs *Scope) TraceRepeatContext(ctx context.Context, cfg TraceConfig, n int, handler func(data []byte) bool) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.repeat(ctx, cfg, n, func(data, r []byte, t time.Time) bool {
+		return handler(data)
+	})
+}
+
+// repeat is TraceRepeat, calling handler also with the response to 'D' and
+// the time at which each trace completed.
+func (bs *Scope) repeat(ctx context.Context, cfg TraceConfig, n int, handler func(data, r []byte, t time.Time) bool) error {
+
+	size := cfg.Size
+	if size == 0 {
+		size = cfg.Pre + cfg.Post
+	}
+
+	c := captureAnalog
+
+	if err := bs.traceSetup(c, cfg.Pre, cfg.Post, cfg.Delay); err != nil {
+		return err
+	}
+
+	for i := 0; n == 0 || i < n; i++ {
+
+		if i > 0 {
+			// The dump has moved the buffer address
+			err := bs.pipeline(ctx,
+				reg(RegBufferMode, c.bufferMode),
+				reg(RegSampleAddress, 0),
+				[]byte(">"),
+				[]byte("U"))
+			if err != nil {
+				return err
+			}
+		}
+
+		r, err := bs.traceStart(ctx)
+		if err != nil {
+			return err
+		}
+		t := time.Now()
+
+		data, err := bs.dump(ctx, size)
+		if err != nil {
+			return err
+		}
+
+		if !handler(data, r, t) {
+			break
+		}
+	}
+
+	return nil
+}
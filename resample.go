@@ -0,0 +1,114 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"errors"
+	"math"
+)
+
+// Half width of the interpolation kernel of SincAt, in samples
+const sincTaps = 8
+
+// Time returns the time of sample i, in seconds after the trigger event.
+// Traces at different sample rates are compared on this time base.
+func (t *Trace) Time(i int) float64 {
+	return float64(i-t.TriggerIndex) / t.SampleRate
+}
+
+// LinearAt returns the value of the trace (see Volts) at time s (see
+// Time), interpolated linearly between the nearest samples. It returns
+// NaN outside of the trace.
+func (t *Trace) LinearAt(s float64) float64 {
+
+	v := t.Volts()
+	x := float64(t.TriggerIndex) + s*t.SampleRate
+	if len(v) == 0 || x < 0 || x > float64(len(v)-1) {
+		return math.NaN()
+	}
+
+	i := int(x)
+	if i == len(v)-1 {
+		return v[i]
+	}
+	f := x - float64(i)
+	return v[i]*(1-f) + v[i+1]*f
+}
+
+// SincAt returns the value of the trace at time s, with band limited
+// (windowed sinc) interpolation, which reconstructs signals below half the
+// sample rate better than LinearAt. It returns NaN outside of the trace.
+func (t *Trace) SincAt(s float64) float64 {
+	v := t.Volts()
+	x := float64(t.TriggerIndex) + s*t.SampleRate
+	if len(v) == 0 || x < 0 || x > float64(len(v)-1) {
+		return math.NaN()
+	}
+	return sincAt(v, x, 1)
+}
+
+// sincAt interpolates v at position x with a Lanczos kernel whose cut-off
+// is fc times half the sample rate (fc <= 1, for decimation).
+func sincAt(v []float64, x, fc float64) float64 {
+
+	w := sincTaps / fc
+	lo := int(math.Max(0, math.Ceil(x-w)))
+	hi := int(math.Min(float64(len(v)-1), math.Floor(x+w)))
+
+	sum, norm := 0.0, 0.0
+	for k := lo; k <= hi; k++ {
+		d := x - float64(k)
+		g := fc * sinc(fc*d) * sinc(d/w)
+		sum += v[k] * g
+		norm += g
+	}
+	if norm == 0 {
+		return 0
+	}
+	// Normalized, so that a constant signal stays constant at the edges
+	return sum / norm
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// Resample returns the trace at another sample rate, as a derived trace
+// (see Values) with the same time base (see Time), computed with SincAt.
+// When the rate is lowered, the signal is first limited to half the new
+// rate, to avoid aliasing.
+func (t *Trace) Resample(rate float64) (*Trace, error) {
+
+	v := t.Volts()
+	if len(v) == 0 || t.SampleRate == 0 {
+		return nil, errors.New("Trace cannot be resampled")
+	}
+	if rate <= 0 {
+		return nil, ErrSampleRate
+	}
+
+	step := t.SampleRate / rate
+	fc := math.Min(1, 1/step)
+
+	// The first new sample is the earliest one within the trace, at a
+	// whole number of new samples from the trigger
+	trig := int(math.Floor(float64(t.TriggerIndex) / step))
+	x0 := float64(t.TriggerIndex) - float64(trig)*step
+
+	n := int(math.Floor((float64(len(v)-1)-x0)/step)) + 1
+	d := *t
+	d.Samples = nil
+	d.SampleRate = rate
+	d.TriggerIndex = trig
+	d.Values = make([]float64, n)
+	if d.Unit == "" {
+		d.Unit = "V"
+	}
+	for j := range d.Values {
+		d.Values[j] = sincAt(v, x0+float64(j)*step, fc)
+	}
+	return &d, nil
+}
@@ -0,0 +1,87 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrOutOfSync is returned by Resync when the responses of the BitScope
+// cannot be aligned with the commands sent.
+var ErrOutOfSync = errors.New("BitScope responses out of sync")
+
+// flushTimeout is the time without data after which the input is
+// considered drained.
+const flushTimeout = 50 * time.Millisecond
+
+// Flush discards the bytes that the BitScope has sent but have not been
+// read, e.g. the late response to a command that timed out.
+func (bs *Scope) Flush() error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.flush()
+}
+
+func (bs *Scope) flush() error {
+
+	defer bs.tty.SetDeadline(time.Time{})
+
+	r := make([]byte, 256)
+
+	for {
+		bs.tty.SetDeadline(time.Now().Add(flushTimeout))
+
+		// A read that times out without data returns (0, nil), also on
+		// a POSIX tty (see Transport): the input is drained
+		n, err := bs.tty.Read(r)
+		if err != nil {
+			return bs.lost(err)
+		}
+		if n == 0 {
+			return nil
+		}
+	}
+}
+
+// Resync drains the input, sends an ID request and checks that the answer
+// is the ID of the BitScope, so that the next response corresponds to the
+// next command. It is called automatically after a timeout.
+func (bs *Scope) Resync() error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if err := bs.checkArmed(); err != nil {
+		return err
+	}
+	return bs.resync()
+}
+
+func (bs *Scope) resync() error {
+
+	const tries = 3
+
+	// Nothing to compare with before the BitScope is identified
+	if bs.resyncing || bs.ID == "" {
+		return nil
+	}
+	bs.resyncing = true
+	defer func() { bs.resyncing = false }()
+
+	for i := 0; i < tries; i++ {
+
+		if err := bs.flush(); err != nil {
+			return err
+		}
+
+		if bs.id(context.Background()) == bs.ID {
+			return nil
+		}
+	}
+
+	return ErrOutOfSync
+}
@@ -0,0 +1,50 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"time"
+)
+
+// SetRetry sets the number of times a command is repeated when it fails
+// with a transient error (timeout, short read, echo mismatch), and the
+// delay before the first retry, which is doubled at each retry. The
+// BitScope is resynchronized before each retry.
+//
+// Only commands that can safely be repeated are retried: register writes,
+// identification and dumps, but not the start of a trace.
+func (bs *Scope) SetRetry(n int, backoff time.Duration) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	bs.retries = n
+	bs.backoff = backoff
+}
+
+// exchangeRetry is exchange with the retry policy of the Scope.
+func (bs *Scope) exchangeRetry(ctx context.Context, cmd []byte, f frame, timeout time.Duration) (*response, error) {
+
+	wait := bs.backoff
+
+	for i := 0; ; i++ {
+
+		r, err := bs.exchange(ctx, cmd, f, timeout)
+		if err == nil || i >= bs.retries || !transient(err) {
+			return r, err
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return r, err
+		}
+		wait *= 2
+	}
+}
+
+// transient returns true for errors after which a command can be retried.
+func transient(err error) bool {
+	return err == ErrTimeout || err == ErrOutOfSync
+}
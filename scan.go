@@ -0,0 +1,83 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// DeviceInfo describes a BitScope found by Scan.
+type DeviceInfo struct {
+	// The port to be given to Open
+	Port string
+	// The ID string returned by the BitScope
+	ID string
+	// The model of the scope ('bs10' or 'bs05')
+	Model string
+}
+
+// probeTimeout is the time given to a device to answer the ID request.
+const probeTimeout = 500 * time.Millisecond
+
+// Scan probes the serial ports of the system (/dev/ttyUSB*,
+// /dev/serial/by-id/* on Linux, /dev/cu.usbserial* on macOS, COMn on
+// Windows) and returns the BitScopes that answer to an ID request.
+//
+// Ports that are open by other programs, or that are not connected to a
+// supported BitScope, are skipped.
+func Scan() ([]DeviceInfo, error) {
+
+	var list []DeviceInfo
+
+	for _, port := range ports() {
+
+		bs, err := probe(port)
+		if err != nil {
+			continue
+		}
+
+		list = append(list, DeviceInfo{port, bs.ID, bs.Model})
+		bs.Close()
+	}
+
+	return list, nil
+}
+
+// OpenByID opens the first BitScope whose ID string starts with the given
+// prefix. This selects a specific instrument when several are attached,
+// since port numbers may change across reboots.
+func OpenByID(prefix string) (*Scope, error) {
+
+	for _, port := range ports() {
+
+		bs, err := probe(port)
+		if err != nil {
+			continue
+		}
+
+		if strings.HasPrefix(bs.ID, prefix) {
+			return bs, nil
+		}
+		bs.Close()
+	}
+
+	return nil, errors.New("No BitScope found with ID " + prefix)
+}
+
+// probe opens a port and identifies the BitScope attached to it, without
+// waiting forever for devices that do not answer.
+func probe(port string) (*Scope, error) {
+
+	tty, err := openSerial(port, &Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	return newScope(ctx, tty)
+}
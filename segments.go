@@ -0,0 +1,46 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"time"
+)
+
+// Segment is one of the traces captured by Segments.
+type Segment struct {
+	Data []byte
+	// Time at which the trace completed
+	Time time.Time
+	// Index in Data of the sample at the trigger event
+	TriggerIndex int
+}
+
+// Segments captures n short triggered traces back to back, re-arming the
+// trigger as fast as possible after each of them (see TraceRepeat), and
+// returns them with their times. Keeping the traces short (small Pre and
+// Post) keeps the dead time between them short.
+func (bs *Scope) Segments(cfg TraceConfig, n int) ([]Segment, error) {
+	return bs.SegmentsContext(context.Background(), cfg, n)
+}
+
+// SegmentsContext is Segments with a context. When ctx is done, the
+// segments captured so far are returned with the error of ctx.
+func (bs *Scope) SegmentsContext(ctx context.Context, cfg TraceConfig, n int) ([]Segment, error) {
+
+	if n < 1 {
+		return nil, nil
+	}
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	seg := make([]Segment, 0, n)
+
+	err := bs.repeat(ctx, cfg, n, func(data, r []byte, t time.Time) bool {
+		seg = append(seg, Segment{data, t, triggerIndex(r, cfg.Pre)})
+		return true
+	})
+
+	return seg, err
+}
@@ -0,0 +1,111 @@
+// For the license see the LICENSE file (BSD style)
+
+//go:build !windows
+// +build !windows
+
+package bitscope
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/term"
+)
+
+// serial is the Transport of a tty.
+type serial struct {
+	*term.Term
+	timeout time.Duration
+}
+
+// portName converts a port number into a device path. Other names are
+// returned unchanged.
+func portName(dev string) string {
+
+	const base string = "/dev/ttyUSB"
+
+	switch len(dev) {
+
+	case 0:
+		dev = base + "0"
+	case 1:
+		fallthrough
+	case 2:
+		dev = base + dev
+
+	}
+
+	return dev
+}
+
+// ports returns the device paths where a BitScope may be attached. Symbolic
+// links (/dev/serial/by-id) pointing to a port already in the list are
+// skipped.
+func ports() []string {
+
+	var list []string
+	seen := make(map[string]bool)
+
+	for _, pattern := range []string{
+		"/dev/ttyUSB*",
+		"/dev/serial/by-id/*",
+		"/dev/cu.usbserial*",
+		"/dev/ttyU*",
+	} {
+		m, _ := filepath.Glob(pattern)
+
+		for _, dev := range m {
+			real, err := filepath.EvalSymlinks(dev)
+			if err != nil || seen[real] {
+				continue
+			}
+			seen[real] = true
+			list = append(list, dev)
+		}
+	}
+
+	return list
+}
+
+// openSerial opens the tty and configures it through termios: raw mode at
+// the link speed. No external tools (stty) are needed.
+func openSerial(dev string, opt *Options) (Transport, error) {
+
+	rate := opt.Baud
+	if rate == 0 {
+		rate = baud
+	}
+
+	options := []func(*term.Term) error{term.Speed(rate)}
+	if !opt.NoRaw {
+		options = append(options, term.RawMode)
+	}
+	options = append(options, term.ReadTimeout(opt.ReadTimeout))
+
+	tty, err := term.Open(dev, options...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// A read that times out without data returns io.EOF
+	s := serial{tty, opt.ReadTimeout}
+	return &eofTimeout{Transport: s, timeout: opt.ReadTimeout, timed: opt.ReadTimeout > 0}, nil
+}
+
+// SetDeadline sets the read timeout of the tty. Termios counts in tenths of
+// a second, so the deadline is rounded up to that resolution. A zero value
+// restores the read timeout given when opening.
+func (s serial) SetDeadline(t time.Time) error {
+
+	if t.IsZero() {
+		return s.SetReadTimeout(s.timeout)
+	}
+
+	d := time.Until(t)
+	if d <= 0 {
+		d = 1
+	}
+
+	return s.SetReadTimeout(d)
+}
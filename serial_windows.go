@@ -0,0 +1,230 @@
+// For the license see the LICENSE file (BSD style)
+
+//go:build windows
+// +build windows
+
+package bitscope
+
+import (
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	kernel32            = syscall.NewLazyDLL("kernel32.dll")
+	procGetCommState    = kernel32.NewProc("GetCommState")
+	procSetCommState    = kernel32.NewProc("SetCommState")
+	procSetCommTimeouts = kernel32.NewProc("SetCommTimeouts")
+	procPurgeComm       = kernel32.NewProc("PurgeComm")
+)
+
+// dcb is the Win32 DCB structure that holds the settings of a serial port.
+type dcb struct {
+	DCBlength  uint32
+	BaudRate   uint32
+	Flags      uint32
+	wReserved  uint16
+	XonLim     uint16
+	XoffLim    uint16
+	ByteSize   byte
+	Parity     byte
+	StopBits   byte
+	XonChar    byte
+	XoffChar   byte
+	ErrorChar  byte
+	EofChar    byte
+	EvtChar    byte
+	wReserved1 uint16
+}
+
+// commTimeouts is the Win32 COMMTIMEOUTS structure.
+type commTimeouts struct {
+	ReadIntervalTimeout         uint32
+	ReadTotalTimeoutMultiplier  uint32
+	ReadTotalTimeoutConstant    uint32
+	WriteTotalTimeoutMultiplier uint32
+	WriteTotalTimeoutConstant   uint32
+}
+
+const (
+	dcbBinary          = 0x0001
+	dcbDtrControlOn    = 0x0010
+	dcbRtsControlOn    = 0x1000
+	purgeRxClear       = 0x0008
+	purgeTxClear       = 0x0004
+	maxDword           = 0xffffffff
+	readTimeoutForEver = maxDword - 1
+)
+
+// comPort is a serial port opened through the Win32 API.
+type comPort struct {
+	h       syscall.Handle
+	timeout uint32
+}
+
+// portName converts a port number or a COM name into a device path
+// (\\.\COMn), which is needed for ports above COM9.
+func portName(dev string) string {
+
+	switch len(dev) {
+
+	case 0:
+		dev = "COM1"
+	case 1:
+		fallthrough
+	case 2:
+		dev = "COM" + dev
+
+	}
+
+	if !strings.HasPrefix(dev, `\\.\`) {
+		dev = `\\.\` + dev
+	}
+
+	return dev
+}
+
+// ports returns the COM ports that can be opened. Ports in use by other
+// programs are not listed.
+func ports() []string {
+
+	var list []string
+
+	for i := 1; i <= 64; i++ {
+		dev := portName("COM" + strconv.Itoa(i))
+
+		name, _ := syscall.UTF16PtrFromString(dev)
+		h, err := syscall.CreateFile(name,
+			syscall.GENERIC_READ|syscall.GENERIC_WRITE,
+			0, nil, syscall.OPEN_EXISTING, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+		if err != nil {
+			continue
+		}
+		syscall.CloseHandle(h)
+		list = append(list, dev)
+	}
+
+	return list
+}
+
+// openSerial opens the COM port and configures it for raw 8N1 operation.
+// The NoRaw option keeps the current port settings except for the speed.
+func openSerial(dev string, opt *Options) (Transport, error) {
+
+	name, err := syscall.UTF16PtrFromString(dev)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := syscall.CreateFile(name,
+		syscall.GENERIC_READ|syscall.GENERIC_WRITE,
+		0, nil, syscall.OPEN_EXISTING, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &comPort{h, readTimeoutForEver}
+	if opt.ReadTimeout > 0 {
+		p.timeout = uint32(opt.ReadTimeout / time.Millisecond)
+	}
+
+	rate := opt.Baud
+	if rate == 0 {
+		rate = baud
+	}
+
+	if err = p.setRaw(rate, !opt.NoRaw); err != nil {
+		p.Close()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// setRaw sets the baud rate and if raw is set, 8 data bits, no parity, 1
+// stop bit and no flow control. Reads block until at least one byte is
+// available or the read timeout expires.
+func (p *comPort) setRaw(rate int, raw bool) error {
+
+	var d dcb
+	d.DCBlength = uint32(unsafe.Sizeof(d))
+
+	if r, _, err := procGetCommState.Call(uintptr(p.h), uintptr(unsafe.Pointer(&d))); r == 0 {
+		return err
+	}
+
+	d.BaudRate = uint32(rate)
+	if raw {
+		d.Flags = dcbBinary | dcbDtrControlOn | dcbRtsControlOn
+		d.ByteSize = 8
+		d.Parity = 0
+		d.StopBits = 0
+	}
+
+	if r, _, err := procSetCommState.Call(uintptr(p.h), uintptr(unsafe.Pointer(&d))); r == 0 {
+		return err
+	}
+
+	if err := p.setReadTimeout(p.timeout); err != nil {
+		return err
+	}
+
+	procPurgeComm.Call(uintptr(p.h), purgeRxClear|purgeTxClear)
+
+	return nil
+}
+
+// setReadTimeout makes reads return as soon as a byte is available, or
+// after the given number of ms without data.
+func (p *comPort) setReadTimeout(ms uint32) error {
+
+	t := commTimeouts{
+		ReadIntervalTimeout:        maxDword,
+		ReadTotalTimeoutMultiplier: maxDword,
+		ReadTotalTimeoutConstant:   ms,
+	}
+
+	if r, _, err := procSetCommTimeouts.Call(uintptr(p.h), uintptr(unsafe.Pointer(&t))); r == 0 {
+		return err
+	}
+
+	return nil
+}
+
+// SetDeadline sets the read timeout of the port. A zero value restores the
+// read timeout given when opening.
+func (p *comPort) SetDeadline(t time.Time) error {
+
+	if t.IsZero() {
+		return p.setReadTimeout(p.timeout)
+	}
+
+	ms := time.Until(t) / time.Millisecond
+	if ms <= 0 {
+		ms = 1
+	}
+	if ms >= readTimeoutForEver {
+		ms = readTimeoutForEver - 1
+	}
+
+	return p.setReadTimeout(uint32(ms))
+}
+
+func (p *comPort) Read(b []byte) (int, error) {
+	var n uint32
+	err := syscall.ReadFile(p.h, b, &n, nil)
+	return int(n), err
+}
+
+func (p *comPort) Write(b []byte) (int, error) {
+	var n uint32
+	err := syscall.WriteFile(p.h, b, &n, nil)
+	return int(n), err
+}
+
+func (p *comPort) Close() error {
+	return syscall.CloseHandle(p.h)
+}
@@ -0,0 +1,497 @@
+// For the license see the LICENSE file (BSD style)
+
+// Package sim simulates the virtual machine of the BitScope BS05 and BS10,
+// so that programs can be tested without an instrument. A VM is a
+// Transport for bitscope.New:
+//
+//	bs, err := bitscope.New(sim.New("bs10"))
+//
+// The simulator implements the register file, the identification ('?'),
+// reset ('!') and peek ('p') commands, a trace engine that fills the
+// sample buffer from synthetic signals ('D'), a dump engine, binary
+// ('A') and text ('S'), and the waveform memory of the generator ('E').
+// Every command byte is echoed, as the VM does.
+//
+// A trace completes at once, with the trigger after the pre-trigger
+// samples. Only the logic trigger of a logic trace is simulated: the trace
+// is taken so that the trigger falls on the first sample after the
+// pre-trigger ones at which the inputs match TriggerLogic, or at which the
+// match starts (ends) in edge mode; without one, the trigger is placed
+// anyway. The part of the buffer after the trace
+// holds the Prelude value. In frequency mode, the number of rising edges of
+// the trigger source during the post-trigger samples is stored in the
+// sample counter.
+//
+// The signals are indexed by sample clock. In dual channel (chop) mode,
+// CHA takes the even clocks and CHB the odd ones, so that CHB is sampled
+// one clock after CHA, as by the VM.
+package sim
+
+import (
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// Registers used by the simulator.
+const (
+	regSampleAddress = 0x08
+	regTriggerLogic  = 0x05
+	regTriggerMask   = 0x06
+	regSpockOption   = 0x07
+	regSampleCounter = 0x0b
+	regDumpRepeat    = 0x16
+	regDumpSend      = 0x18
+	regDumpSkip      = 0x1a
+	regDumpCount     = 0x1c
+	regDumpMode      = 0x1e
+	regTraceMode     = 0x21
+	regTraceIntro    = 0x26
+	regTraceOutro    = 0x2a
+	regPrelude       = 0x3a
+	regAwgIndex      = 0x4c
+)
+
+// Trace modes
+const (
+	modeAnalog     = 0
+	modeMixed      = 1
+	modeAnalogChop = 2
+	modeFrequency  = 8
+	modeLogic      = 14
+	modeMacro      = 18
+)
+
+// Dump modes
+const modeFilter = 6
+
+// BufferSize is the size of the sample buffer, in bytes.
+const BufferSize = 12 * 1024
+
+// WaveSize is the size of the waveform memory of the generator, in bytes.
+const WaveSize = 4096
+
+// DumpBase is the buffer address that holds the first sample of a trace.
+const DumpBase = 0xcc
+
+// VM is a simulated BitScope. Its exported fields can be changed before it
+// is used.
+type VM struct {
+	// ID is the string returned by the '?' command
+	ID string
+	// Signal returns the ADC code of sample i of channel A (default: a sine
+	// wave with a period of 64 samples)
+	Signal func(i int) byte
+	// SignalB returns the ADC code of sample i of channel B (default: a
+	// cosine wave with a period of 64 samples)
+	SignalB func(i int) byte
+	// Logic returns the state of the 8 logic inputs at sample i (default:
+	// a binary counter)
+	Logic func(i int) byte
+	// Number of reads without data after each write, as if the VM took
+	// that long to answer (default: none)
+	Stall int
+	// Return io.EOF from a read without data, as a POSIX tty does when its
+	// read timeout expires, instead of (0, nil)
+	EOFOnTimeout bool
+
+	mu     sync.Mutex
+	stall  int
+	reg    [256]byte
+	addr   byte
+	value  uint
+	buf    []byte
+	wave   [WaveSize]byte
+	traces int
+	out    []byte
+	closed bool
+}
+
+// New returns a simulated BitScope of the given model ("bs10" or "bs05").
+func New(model string) *VM {
+
+	id := "BS001001"
+	if model == "bs05" {
+		id = "BS000501"
+	}
+
+	return &VM{ID: id, Signal: Sine, SignalB: Cosine, Logic: Counter}
+}
+
+// Sine is a sine wave with a period of 64 samples.
+func Sine(i int) byte {
+	return byte(128 + 100*math.Sin(2*math.Pi*float64(i)/64))
+}
+
+// Cosine is a cosine wave with a period of 64 samples.
+func Cosine(i int) byte {
+	return byte(128 + 100*math.Cos(2*math.Pi*float64(i)/64))
+}
+
+// Counter is a binary counter, incremented at each sample.
+func Counter(i int) byte {
+	return byte(i)
+}
+
+// Write interprets the VM commands in b.
+func (m *VM) Write(b []byte) (int, error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return 0, io.ErrClosedPipe
+	}
+	m.stall = m.Stall
+
+	for _, c := range b {
+
+		m.out = append(m.out, c)
+
+		switch {
+
+		case c >= '0' && c <= '9':
+			m.value = m.value<<4 | uint(c-'0')
+		case c >= 'a' && c <= 'f':
+			m.value = m.value<<4 | uint(c-'a'+10)
+
+		case c == '[':
+			m.value = 0
+		case c == ']':
+
+		case c == '@':
+			m.addr = byte(m.value)
+			m.value = 0
+		case c == 's':
+			m.reg[m.addr] = byte(m.value)
+			m.value = 0
+		case c == 'z':
+			m.reg[m.addr] = byte(m.value)
+			m.addr++
+			m.value = 0
+		case c == 'n':
+			m.addr++
+		case c == 'p':
+			v := m.reg[m.addr]
+			m.out = append(m.out, '\r', hex[v>>4], hex[v&15], '\r')
+
+		case c == '?':
+			m.out = append(m.out, "\r"+m.ID+"\r"...)
+		case c == '!':
+			m.reg = [256]byte{}
+			m.buf = nil
+
+		case c == 'D':
+			m.trace()
+
+		case c == 'A':
+			m.dump(false)
+		case c == 'S':
+			m.dump(true)
+
+		case c == 'E':
+			i := m.get(regAwgIndex, 2) % WaveSize
+			m.wave[i] = byte(m.value)
+			m.put(regAwgIndex, 2, i+1)
+			m.value = 0
+
+		default:
+			// '>', 'U', 'K', 'T', 'Y', '.' and unknown commands: echo only
+		}
+	}
+
+	return len(b), nil
+}
+
+const hex = "0123456789abcdef"
+
+// get returns the little endian value of n bytes from register r on.
+func (m *VM) get(r, n int) int {
+	v := 0
+	for i := n - 1; i >= 0; i-- {
+		v = v<<8 | int(m.reg[r+i])
+	}
+	return v
+}
+
+// put stores a little endian value of n bytes from register r on.
+func (m *VM) put(r, n, v int) {
+	for i := 0; i < n; i++ {
+		m.reg[r+i] = byte(v >> (8 * uint(i)))
+	}
+}
+
+// trace fills the buffer according to the trace mode and writes the
+// response of 'D': status, timestamp, and the start, trigger and end
+// addresses of the trace.
+func (m *VM) trace() {
+
+	pre := m.get(regTraceIntro, 2)
+	post := m.get(regTraceOutro, 2)
+
+	// Samples are taken at a running time, so that consecutive traces differ
+	t := m.traces * BufferSize
+	m.traces++
+
+	if m.reg[regTraceMode] == modeLogic {
+		t = m.logicTrigger(t+pre) - pre
+	}
+
+	m.buf = make([]byte, BufferSize)
+
+	for i := range m.buf {
+
+		k := t + i
+		var v byte
+
+		switch m.reg[regTraceMode] {
+		case modeMixed:
+			if i%2 == 0 {
+				v = m.Signal(k / 2)
+			} else {
+				v = m.Logic(k / 2)
+			}
+		case modeAnalogChop:
+			// Each channel is sampled at every other clock
+			if i%2 == 0 {
+				v = m.Signal(k)
+			} else {
+				v = m.SignalB(k)
+			}
+		case modeLogic:
+			v = m.Logic(k)
+		case modeMacro:
+			// Two converters take the even and odd samples at twice
+			// the rate, and store them in the two halves of the buffer
+			const half = BufferSize / 2
+			j := t + i%half*2 + i/half
+			v = m.Signal(j)
+		default:
+			v = m.Signal(k)
+		}
+		m.buf[i] = v
+	}
+
+	n := pre + post
+	if n > BufferSize {
+		n = BufferSize
+	}
+	m.put(regSampleCounter, 3, n)
+
+	if m.reg[regTraceMode] == modeFrequency {
+		m.put(regSampleCounter, 3, m.edges(t, post))
+	}
+
+	// The rest of the buffer keeps the prelude value
+	if m.reg[regTraceMode] != modeMacro {
+		for i := n; i < BufferSize; i++ {
+			m.buf[i] = m.reg[regPrelude]
+		}
+	}
+
+	start := DumpBase
+	trig := start + pre
+	end := start + n
+	m.put(regSampleAddress, 3, end)
+
+	m.out = append(m.out, "\r00\r"...)
+	m.out = append(m.out, hexN(uint(m.traces), 8)...)
+	for _, a := range []int{start, trig, end} {
+		m.out = append(m.out, '\r')
+		m.out = append(m.out, hexN(uint(a), 6)...)
+	}
+	m.out = append(m.out, '\r')
+}
+
+// logicTrigger returns the first sample from sample t on at which the
+// logic trigger fires, or t if it does not within a buffer. The comparator
+// bit of SpockOption selects the analog trigger instead.
+func (m *VM) logicTrigger(t int) int {
+
+	spock := m.reg[regSpockOption]
+	if spock&1 != 0 {
+		return t
+	}
+
+	match := func(i int) bool {
+		v := (m.Logic(i) ^ m.reg[regTriggerLogic]) &^ m.reg[regTriggerMask]
+		return (v == 0) != (spock&0x40 != 0)
+	}
+
+	for i := t; i < t+BufferSize; i++ {
+		switch {
+		case spock&0x20 == 0:
+			if match(i) {
+				return i
+			}
+		case spock&0x10 == 0:
+			if match(i) && !match(i-1) {
+				return i
+			}
+		default:
+			if !match(i) && match(i-1) {
+				return i
+			}
+		}
+	}
+	return t
+}
+
+// edges returns the number of rising edges (crossings of mid-scale) of the
+// trigger source in the n samples from sample t on.
+func (m *VM) edges(t, n int) int {
+
+	sig := m.Signal
+	if m.reg[regSpockOption]&4 != 0 {
+		sig = m.SignalB
+	}
+
+	c := 0
+	for i := t; i < t+n; i++ {
+		if sig(i) < 128 && sig(i+1) >= 128 {
+			c++
+		}
+	}
+	return c
+}
+
+// dump writes DumpCount bytes of the buffer, from the address in
+// SampleAddress on, in binary or as hex text, one sample per line. Without
+// a previous trace, the samples are taken from Signal directly.
+//
+// The dump engine sends groups of DumpSend samples, DumpRepeat times each,
+// and skips DumpSkip samples (0xffff: none) after each group. In filter
+// mode each byte sent is the mean of the samples sent and skipped instead.
+func (m *VM) dump(text bool) {
+
+	n := m.get(regDumpCount, 2)
+	a := m.get(regSampleAddress, 3) - DumpBase
+
+	repeat := m.get(regDumpRepeat, 2)
+	send := m.get(regDumpSend, 2)
+	skip := m.get(regDumpSkip, 2)
+	if repeat == 0 {
+		repeat = 1
+	}
+	if send == 0 {
+		send = 1
+	}
+	if skip == 0xffff {
+		skip = 0
+	}
+	filter := m.reg[regDumpMode] == modeFilter
+
+	for i := 0; i < n; i++ {
+
+		// Buffer position of the group of byte i, and of byte i
+		g := a + i/(send*repeat)*(send+skip)
+		k := g + i%(send*repeat)%send
+
+		var v byte
+		if filter {
+			sum := 0
+			for j := 0; j < send+skip; j++ {
+				sum += int(m.sample(g + j))
+			}
+			v = byte(sum / (send + skip))
+		} else {
+			v = m.sample(k)
+		}
+
+		if text {
+			m.out = append(m.out, hex[v>>4], hex[v&15], '\r')
+		} else {
+			m.out = append(m.out, v)
+		}
+	}
+}
+
+// sample returns the sample at position k of the buffer.
+func (m *VM) sample(k int) byte {
+
+	if m.buf == nil {
+		return m.Signal(k)
+	}
+
+	k %= BufferSize
+	if k < 0 {
+		k += BufferSize
+	}
+	return m.buf[k]
+}
+
+// hexN returns n as a hex number of the given number of digits.
+func hexN(n uint, digits int) []byte {
+	b := make([]byte, digits)
+	for i := digits - 1; i >= 0; i-- {
+		b[i] = hex[n&15]
+		n >>= 4
+	}
+	return b
+}
+
+// Read returns the pending response bytes. If there are none, it returns 0
+// bytes, as a serial port whose read timeout expired.
+func (m *VM) Read(b []byte) (int, error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return 0, io.EOF
+	}
+
+	if m.stall > 0 || len(m.out) == 0 {
+		if m.stall > 0 {
+			m.stall--
+		}
+		if m.EOFOnTimeout {
+			return 0, io.EOF
+		}
+		return 0, nil
+	}
+
+	n := copy(b, m.out)
+	m.out = m.out[n:]
+
+	return n, nil
+}
+
+// SetDeadline does nothing: reads never block, they return at once without
+// data (see Stall and EOFOnTimeout).
+func (m *VM) SetDeadline(t time.Time) error {
+	return nil
+}
+
+// Close marks the transport as closed. Later reads return io.EOF.
+func (m *VM) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+	return nil
+}
+
+// Reg returns the value of a VM register, as last written.
+func (m *VM) Reg(addr byte) byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.reg[addr]
+}
+
+// Buffer returns a copy of the sample buffer, as filled by the last trace.
+func (m *VM) Buffer() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]byte(nil), m.buf...)
+}
+
+// Wave returns a copy of the first n bytes of the waveform memory.
+func (m *VM) Wave(n int) []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n > WaveSize {
+		n = WaveSize
+	}
+	return append([]byte(nil), m.wave[:n]...)
+}
@@ -0,0 +1,23 @@
+// For the license see the LICENSE file (BSD style)
+
+package sim_test
+
+import (
+	"fmt"
+
+	"bitscope"
+	"bitscope/sim"
+)
+
+func Example() {
+	m := sim.New("bs10")
+	m.Signal = func(i int) byte { return byte(i % 100) }
+
+	bs, _ := bitscope.New(m)
+	defer bs.Close()
+
+	bs.Trace(16, 256, 0)
+	b, err := bs.Dump(4)
+	fmt.Println(b, err)
+	// Output: [0 1 2 3] <nil>
+}
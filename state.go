@@ -0,0 +1,166 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// State is a snapshot of the configuration registers of a BitScope, as
+// returned by SaveState. It can be stored as JSON, with the registers by
+// name:
+//
+//	{"id":"BS001001","model":"bs10","registers":{"TraceDelay":0,...}}
+type State struct {
+	ID        string            `json:"id"`
+	Model     string            `json:"model"`
+	Registers map[Register]uint `json:"registers"`
+}
+
+// volatile holds the named registers that are not part of a State: the
+// status and counters of the VM, the registers set up for each dump or
+// generator command, and the LEDs, which show the status.
+var volatile = map[Register]bool{
+	RegSampleAddress: true,
+	RegSampleCounter: true,
+	RegDumpRepeat:    true,
+	RegDumpSend:      true,
+	RegDumpSkip:      true,
+	RegDumpCount:     true,
+	RegDumpMode:      true,
+	RegDumpChan:      true,
+	RegAwgCmd:        true,
+	RegAwgIndex:      true,
+	RegLedRed:        true,
+	RegLedGreen:      true,
+	RegLedYellow:     true,
+}
+
+// SaveState reads back the named configuration registers and returns them,
+// together with the identification of the BitScope. The status and
+// counters, the dump registers and the LEDs are left out.
+func (bs *Scope) SaveState() (*State, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	s := &State{ID: bs.ID, Model: bs.Model, Registers: make(map[Register]uint, len(regNames))}
+
+	for r := range regNames {
+		if volatile[r] {
+			continue
+		}
+		v, err := bs.readReg(context.Background(), r)
+		if err != nil {
+			return nil, err
+		}
+		s.Registers[r] = v
+	}
+	return s, nil
+}
+
+// RestoreState writes the registers of a saved state, for example after a
+// Reset. The registers are written again after a reconnection. Those that
+// SaveState leaves out are skipped. A state saved from another model is
+// refused.
+func (bs *Scope) RestoreState(s *State) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if s.Model != "" && s.Model != bs.Model {
+		return errors.New("State of a " + s.Model + " cannot be restored on a " + bs.Model)
+	}
+
+	var cmds [][]byte
+	for _, r := range s.sorted() {
+		if volatile[r] {
+			continue
+		}
+		b := reg(r, s.Registers[r])
+		bs.remember("reg"+string(hexByte(uint(r))), b)
+		cmds = append(cmds, b)
+	}
+
+	// Registers that are also programmed by each trace
+	if v, ok := s.Registers[RegTriggerLevel]; ok {
+		bs.trigLevel = v
+	}
+	if v, ok := s.Registers[RegTriggerLogic]; ok {
+		bs.trigLogic = v
+	}
+	if v, ok := s.Registers[RegTriggerMask]; ok {
+		bs.trigMask = v
+	}
+	if v, ok := s.Registers[RegSpockOption]; ok {
+		bs.spock = v
+	}
+
+	return bs.pipeline(context.Background(), append(cmds, []byte(">"))...)
+}
+
+// Diff returns the registers whose values differ in s and o, or that are
+// only present in one of them, in address order.
+func (s *State) Diff(o *State) []Register {
+
+	var d []Register
+
+	for r, v := range s.Registers {
+		if w, ok := o.Registers[r]; !ok || v != w {
+			d = append(d, r)
+		}
+	}
+	for r := range o.Registers {
+		if _, ok := s.Registers[r]; !ok {
+			d = append(d, r)
+		}
+	}
+
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+	return d
+}
+
+// sorted returns the registers of the state in address order.
+func (s *State) sorted() []Register {
+
+	var rr []Register
+	for r := range s.Registers {
+		rr = append(rr, r)
+	}
+
+	sort.Slice(rr, func(i, j int) bool { return rr[i] < rr[j] })
+	return rr
+}
+
+// MarshalText encodes the register by name, so that it can be a JSON key.
+func (r Register) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText accepts a register name (with or without the Reg prefix)
+// or its address in hex (0x..).
+func (r *Register) UnmarshalText(b []byte) error {
+
+	s := strings.TrimPrefix(string(b), "Reg")
+
+	for k, name := range regNames {
+		if name == s {
+			*r = k
+			return nil
+		}
+	}
+
+	if strings.HasPrefix(s, "0x") {
+		n, err := strconv.ParseUint(s[2:], 16, 8)
+		if err == nil {
+			*r = Register(n)
+			return nil
+		}
+	}
+
+	return errors.New("Unknown register " + strconv.Quote(string(b)))
+}
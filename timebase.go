@@ -0,0 +1,146 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Limits of the sample clock, in ticks of the master clock
+const (
+	minTicks = 2
+	maxTicks = 0xffff * 0xffff
+)
+
+// ErrSampleRate is returned for a sample rate that the BitScope cannot
+// produce.
+var ErrSampleRate = errors.New("Sample rate out of range")
+
+// SampleRate returns the sample rate set with Horizontal or SetSampleRate,
+// in samples per second, or 0 if none has been set.
+func (bs *Scope) SampleRate() float64 {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.sampleRate()
+}
+
+// SetSampleRate sets the time base to the sample rate closest to hz that the
+// clock dividers can produce, and returns it.
+func (bs *Scope) SetSampleRate(hz float64) (float64, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return bs.setSampleRate(hz)
+}
+
+func (bs *Scope) setSampleRate(hz float64) (float64, error) {
+
+	if hz <= 0 {
+		return 0, ErrSampleRate
+	}
+
+	ticks := math.Round(masterClock / hz)
+	if ticks < minTicks || ticks > maxTicks {
+		return 0, ErrSampleRate
+	}
+
+	// The smallest prescaler that leaves a divisor within range gives the
+	// finest resolution
+	pre := math.Ceil(ticks / 0xffff)
+	div := math.Round(ticks / pre)
+
+	if err := bs.horizontal(uint(pre), uint(div)); err != nil {
+		return 0, err
+	}
+	return bs.sampleRate(), nil
+}
+
+// SetTimeWindow sets the highest sample rate at which a trace of pre
+// before and post after the trigger fits in the sample buffer. It returns
+// the trace configuration (sample counts) for these times, and the sample
+// rate.
+func (bs *Scope) SetTimeWindow(pre, post time.Duration) (TraceConfig, float64, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	total := (pre + post).Seconds()
+	if pre < 0 || post < 0 || total <= 0 {
+		return TraceConfig{}, 0, errors.New("Invalid time window")
+	}
+
+	// Rounding of the dividers can raise the rate a little, so aim lower
+	hz := math.Min(masterClock/minTicks, 0.99*bufferSize/total)
+
+	rate, err := bs.setSampleRate(hz)
+	if err != nil {
+		return TraceConfig{}, 0, err
+	}
+
+	cfg := TraceConfig{
+		Pre:  uint(math.Round(pre.Seconds() * rate)),
+		Post: uint(math.Round(post.Seconds() * rate)),
+	}
+	return cfg, rate, nil
+}
+
+// Tick of the trigger timeout
+const timeoutTick = 6400 * time.Nanosecond
+
+// TriggerTimingDuration is TriggerTiming with times instead of ticks. The
+// hold-off and hold-on times are converted with the current sample rate, so
+// it must be called again when that changes. The times actually set, after
+// rounding to whole ticks, are returned.
+func (bs *Scope) TriggerTimingDuration(hoff, hon, timeout time.Duration) (time.Duration, time.Duration, time.Duration, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	rate := bs.sampleRate()
+	if rate == 0 {
+		return 0, 0, 0, errors.New("Sample rate not set")
+	}
+	tick := time.Duration(float64(time.Second) / rate)
+
+	var ticks [3]uint
+	for i, d := range []struct{ d, tick time.Duration }{{hoff, tick}, {hon, tick}, {timeout, timeoutTick}} {
+		n := math.Round(float64(d.d) / float64(d.tick))
+		if n < 0 || n > 0xffff {
+			return 0, 0, 0, errors.New("Trigger timing out of range")
+		}
+		ticks[i] = uint(n)
+	}
+
+	if err := bs.triggerTiming(ticks[0], ticks[1], ticks[2]); err != nil {
+		return 0, 0, 0, err
+	}
+
+	// Computed from the rate rather than tick, which is rounded
+	d := func(n uint) time.Duration {
+		return time.Duration(math.Round(float64(n) / rate * float64(time.Second)))
+	}
+	return d(ticks[0]), d(ticks[1]), time.Duration(ticks[2]) * timeoutTick, nil
+}
+
+// TriggerDelay sets the time after the trigger during which no samples are
+// recorded, for the traces that do not give a delay themselves. The delay
+// register counts microseconds, so d is rounded to them; the delay actually
+// set is returned. It can be up to about 71 minutes.
+func (bs *Scope) TriggerDelay(d time.Duration) (time.Duration, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	us := math.Round(float64(d) / float64(time.Microsecond))
+	if us < 0 || us > math.MaxUint32 {
+		return 0, errors.New("Trigger delay out of range")
+	}
+
+	bs.delay = uint(us)
+	return time.Duration(bs.delay) * time.Microsecond, nil
+}
@@ -0,0 +1,224 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Trace is the result of an acquisition with the settings needed to
+// interpret its samples.
+type Trace struct {
+	// ADC codes of the samples
+	Samples []byte
+	// Sample rate in samples per second (0 if unknown)
+	SampleRate float64
+	// Vertical range in volts, as selected with Vertical (0 if unknown)
+	Range float64
+	// Index of the sample at the trigger event, as read back from the
+	// BitScope
+	TriggerIndex int
+	// What ended the wait for the trigger
+	Cause TriggerCause
+	// Time at which the acquisition completed
+	Timestamp time.Time
+	// Channel, 'a' or 'b'
+	Channel byte
+	// Mean removal and probe attenuation of the channel (see SoftwareAC and
+	// Attenuation)
+	SoftwareAC bool
+	Probe      float64
+	// Values of a derived trace (see package math), in Unit, which
+	// replace the samples
+	Values []float64
+	Unit   string
+}
+
+// Acquire does a trace of CHA and dumps it, and returns the samples with
+// their metadata.
+func (bs *Scope) Acquire(cfg TraceConfig) (*Trace, error) {
+	return bs.AcquireContext(context.Background(), cfg)
+}
+
+// AcquireContext is Acquire with a context (see TraceContext).
+func (bs *Scope) AcquireContext(ctx context.Context, cfg TraceConfig) (*Trace, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	size := cfg.Size
+	if size == 0 {
+		size = cfg.Pre + cfg.Post
+	}
+
+	r, err := bs.trace(ctx, cfg.Pre, cfg.Post, cfg.Delay)
+	if err != nil {
+		return nil, err
+	}
+	t := time.Now()
+
+	b, err := bs.dump(ctx, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return bs.newTrace(b, r, cfg.Pre, t), nil
+}
+
+// newTrace returns a Trace of CHA with the samples b, the response r of the
+// VM to 'D', and the current settings.
+func (bs *Scope) newTrace(b, r []byte, pre uint, t time.Time) *Trace {
+	return &Trace{
+		Samples:      b,
+		SampleRate:   bs.sampleRate(),
+		Range:        bs.vrange,
+		TriggerIndex: triggerIndex(r, pre),
+		Cause:        bs.triggerCause(r),
+		Timestamp:    t,
+		Channel:      'a',
+		SoftwareAC:   bs.softAC[0],
+		Probe:        bs.probe(0),
+	}
+}
+
+// Volts returns the samples of the trace converted to volts at the probe
+// tip (see CodeToVolts), without their mean if SoftwareAC is set. It
+// returns nil if the vertical range is unknown. For a derived trace it
+// returns its Values, which need not be volts.
+func (t *Trace) Volts() []float64 {
+	if t.Values != nil {
+		return t.Values
+	}
+	p := t.Probe
+	if p == 0 {
+		p = 1
+	}
+	return codesToVolts(t.Samples, t.Range, p, t.SoftwareAC)
+}
+
+// Volts converts samples of CHA taken with the current settings to volts,
+// as Trace.Volts. It returns nil if no range has been selected with
+// Vertical.
+func (bs *Scope) Volts(samples []byte) []float64 {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return codesToVolts(samples, bs.vrange, bs.probe(0), bs.softAC[0])
+}
+
+// CodeToVolts converts an ADC code to volts, for the given vertical range.
+// The converter calibration set by Vertical maps the range onto the codes,
+// centered on zero: code 0 is -rng/2 and code 255 is +rng/2.
+func CodeToVolts(code byte, rng float64) float64 {
+	return (float64(code)/255 - 0.5) * rng
+}
+
+func codesToVolts(samples []byte, rng, probe float64, ac bool) []float64 {
+
+	if rng == 0 {
+		return nil
+	}
+
+	v := make([]float64, len(samples))
+	mean := 0.0
+	for i, c := range samples {
+		v[i] = CodeToVolts(c, rng) * probe
+		mean += v[i]
+	}
+
+	if ac && len(v) > 0 {
+		mean /= float64(len(v))
+		for i := range v {
+			v[i] -= mean
+		}
+	}
+	return v
+}
+
+// TriggerCause tells how the wait for the trigger of a trace ended.
+type TriggerCause int
+
+const (
+	// A trigger event
+	CauseTrigger TriggerCause = iota
+	// ForceTrigger
+	CauseForced
+	// The trigger timeout (see TriggerTiming)
+	CauseTimeout
+	// TraceTerminate
+	CauseTerminated
+)
+
+func (c TriggerCause) String() string {
+	switch c {
+	case CauseTrigger:
+		return "trigger"
+	case CauseForced:
+		return "forced"
+	case CauseTimeout:
+		return "timeout"
+	case CauseTerminated:
+		return "terminated"
+	}
+	return "unknown"
+}
+
+// triggerCause returns the cause of the end of the trace with response r
+// to 'D'. ForceTrigger and TraceTerminate are recorded by the host, since
+// the VM only reports whether the trace was triggered.
+func (bs *Scope) triggerCause(r []byte) TriggerCause {
+
+	switch atomic.LoadInt32(&bs.stopped) {
+	case stopForced:
+		return CauseForced
+	case stopTerminated:
+		return CauseTerminated
+	}
+	if t, ok := parseTrace(r); ok && t.status != 0 {
+		return CauseTimeout
+	}
+	return CauseTrigger
+}
+
+// traceInfo is the information returned by the VM at the end of a trace:
+// a status (0: triggered), a timestamp, and the buffer addresses of the
+// first sample, the trigger event and the end of the trace.
+type traceInfo struct {
+	status    uint64
+	timestamp uint64
+	start     uint64
+	trigger   uint64
+	end       uint64
+}
+
+// parseTrace parses the response to 'D'.
+func parseTrace(r []byte) (*traceInfo, bool) {
+
+	l := traceFrame.parse([]byte("D"), r).lines
+	if len(l) < 5 {
+		return nil, false
+	}
+
+	var v [5]uint64
+	for i := range v {
+		n, err := strconv.ParseUint(l[i], 16, 32)
+		if err != nil {
+			return nil, false
+		}
+		v[i] = n
+	}
+	return &traceInfo{v[0], v[1], v[2], v[3], v[4]}, true
+}
+
+// triggerIndex returns the index in the dump of the sample at the trigger
+// event, from the response to 'D', or pre if it cannot be read back.
+func triggerIndex(r []byte, pre uint) int {
+	if t, ok := parseTrace(r); ok && t.trigger >= t.start {
+		return int(t.trigger - t.start)
+	}
+	return int(pre)
+}
@@ -0,0 +1,103 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// TrendConfig describes a Trend: the traces acquired, and the interval
+// between the start of their acquisitions.
+type TrendConfig struct {
+	Trace    TraceConfig
+	Interval time.Duration
+}
+
+// TrendPoint is a value of a Trend, with the running statistics of the
+// values measured so far.
+type TrendPoint struct {
+	// Time at which the trace was acquired
+	Time  time.Time
+	Value float64
+	// Error of the acquisition or of the measurement (Value is NaN)
+	Err error
+	// Number of values measured without error, and their minimum,
+	// maximum and mean
+	N              int
+	Min, Max, Mean float64
+}
+
+// Trend logs a measurement over time: it acquires a trace of CHA (see
+// Acquire) every cfg.Interval, or as often as possible if the interval is
+// 0, and sends the value returned by measure for it on the returned
+// channel, such as measure.Vrms or the frequency of measure.Frequency.
+//
+// Measurement errors are sent and the logging goes on. The channel is
+// closed when ctx is done, or after a point with an acquisition error. The
+// Scope is locked for one acquisition at a time, so that other calls can
+// be made during Trend.
+func (bs *Scope) Trend(ctx context.Context, cfg TrendConfig, measure func(*Trace) (float64, error)) <-chan TrendPoint {
+
+	ch := make(chan TrendPoint, 1)
+
+	go func() {
+		defer close(ch)
+
+		var tick <-chan time.Time
+		if cfg.Interval > 0 {
+			t := time.NewTicker(cfg.Interval)
+			defer t.Stop()
+			tick = t.C
+		}
+
+		s := TrendPoint{Min: math.Inf(1), Max: math.Inf(-1)}
+
+		for ctx.Err() == nil {
+
+			t, err := bs.AcquireContext(ctx, cfg.Trace)
+			if ctx.Err() != nil {
+				return
+			}
+
+			p := s
+			p.Value, p.Err = math.NaN(), err
+			if err == nil {
+				p.Time = t.Timestamp
+				p.Value, p.Err = measure(t)
+				if p.Err != nil {
+					p.Value = math.NaN()
+				}
+			} else {
+				p.Time = time.Now()
+			}
+			if p.Err == nil {
+				s.N++
+				s.Min = math.Min(s.Min, p.Value)
+				s.Max = math.Max(s.Max, p.Value)
+				s.Mean += (p.Value - s.Mean) / float64(s.N)
+				p.N, p.Min, p.Max, p.Mean = s.N, s.Min, s.Max, s.Mean
+			}
+
+			select {
+			case ch <- p:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+
+			if tick != nil {
+				select {
+				case <-tick:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,51 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import "time"
+
+// Values of Scope.stopped
+const (
+	stopForced = iota + 1
+	stopTerminated
+)
+
+// TriggerEvent reports that a trace has been triggered.
+type TriggerEvent struct {
+	// Host time at which the end of the trace was reported
+	Time time.Time
+	// Buffer address of the sample at the trigger event
+	Address uint
+}
+
+// NotifyTrigger makes the Scope send a TriggerEvent on ch for each
+// triggered trace, as soon as the BitScope reports its end and before the
+// samples are dumped, so that a user interface can show it at once. Traces
+// ended by the timeout or TraceTerminate are not reported. The Scope does
+// not block sending to ch: events are dropped if it is not ready. A nil ch
+// stops the notifications.
+func (bs *Scope) NotifyTrigger(ch chan<- TriggerEvent) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	bs.trigEvents = ch
+}
+
+// notifyTrigger sends a TriggerEvent for the response r to 'D', if it was
+// triggered.
+func (bs *Scope) notifyTrigger(r []byte, t time.Time) {
+
+	if bs.trigEvents == nil {
+		return
+	}
+	info, ok := parseTrace(r)
+	if !ok || info.status != 0 {
+		return
+	}
+
+	select {
+	case bs.trigEvents <- TriggerEvent{Time: t, Address: uint(info.trigger)}:
+	default:
+	}
+}
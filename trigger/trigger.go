@@ -0,0 +1,35 @@
+// For the license see the LICENSE file (BSD style)
+
+// Package trigger defines the trigger modes of the BitScope analog trigger,
+// for Scope.TriggerMode:
+//
+//	bs.TriggerMode(trigger.Rising, true)
+package trigger
+
+// Mode is the condition on the trigger source that fires the trigger.
+type Mode int
+
+const (
+	// The source crosses the trigger level upwards
+	Rising Mode = iota
+	// The source crosses the trigger level downwards
+	Falling
+	// The source is above the trigger level
+	LevelAbove
+	// The source is below the trigger level
+	LevelBelow
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Rising:
+		return "rising"
+	case Falling:
+		return "falling"
+	case LevelAbove:
+		return "above"
+	case LevelBelow:
+		return "below"
+	}
+	return "unknown"
+}
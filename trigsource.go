@@ -0,0 +1,84 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import "errors"
+
+// TriggerSource is the signal that the trigger looks at.
+type TriggerSource int
+
+const (
+	// Analog channel A (default)
+	TrigCHA TriggerSource = iota
+	// Analog channel B
+	TrigCHB
+	// The logic inputs, with the pattern set by TriggerLogic
+	TrigLogic
+	// The analog input of channel A on the POD header (BS10), through the
+	// comparator of channel A
+	TrigExternal
+)
+
+func (s TriggerSource) String() string {
+	switch s {
+	case TrigCHA:
+		return "cha"
+	case TrigCHB:
+		return "chb"
+	case TrigLogic:
+		return "logic"
+	case TrigExternal:
+		return "external"
+	}
+	return "unknown"
+}
+
+// SetTriggerSource selects the trigger source. Analog sources use the
+// comparator output, which is bit 7 of the trigger logic word, so the logic
+// mask and level are reset to look only at it; the level is set with
+// Trigger or TriggerLevelVolts. The logic source uses the pattern last set
+// with TriggerLogic and the sampled (not comparator) trigger type.
+//
+// The external source needs the hardware comparator, and the channel A
+// input it looks at: while it is selected, CHA is sampled from the POD
+// header too, whatever InputSource says. The input set with InputSource is
+// used again once another source is selected.
+//
+// The mode and edge bits set with TriggerMode are kept, and so is the
+// trigger type for the analog channels.
+func (bs *Scope) SetTriggerSource(src TriggerSource) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	mode := bs.spock &^ 4
+	level, mask := uint(0x80), uint(0x7f)
+
+	switch src {
+	case TrigCHA:
+		bs.trigSrc = 'a'
+	case TrigCHB:
+		bs.trigSrc = 'b'
+		mode |= 4
+	case TrigExternal:
+		if bs.Model != "bs10" {
+			return errors.New("External trigger is only available on the BS10")
+		}
+		bs.trigSrc = 'a'
+		mode |= 1
+	case TrigLogic:
+		level, mask = bs.trigLogic, bs.trigMask
+		mode &^= 1
+	default:
+		return errors.New("Unknown trigger source")
+	}
+
+	if err := bs.triggerLogic(level, mask); err != nil {
+		return err
+	}
+	if err := bs.triggerMode(mode); err != nil {
+		return err
+	}
+	bs.trigSource = src
+	return nil
+}
@@ -0,0 +1,28 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+// USBDevice is a USB serial adapter that may have a BitScope behind it.
+type USBDevice struct {
+	// The tty node of the adapter, to be given to Open
+	Port string
+	// USB vendor and product IDs
+	VID, PID uint16
+	// The USB serial number (if the adapter has one)
+	Serial string
+}
+
+// usbIDs are the VID/PID pairs of the FTDI chips used in BitScopes.
+var usbIDs = [][2]uint16{
+	{0x0403, 0x6001}, // FT232R
+	{0x0403, 0x6015}, // FT230X
+}
+
+func isBitScopeUSB(vid, pid uint16) bool {
+	for _, id := range usbIDs {
+		if id[0] == vid && id[1] == pid {
+			return true
+		}
+	}
+	return false
+}
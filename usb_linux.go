@@ -0,0 +1,69 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// USBDevices returns the USB serial adapters with a BitScope VID/PID and
+// the tty nodes they are mapped to. The devices are found through sysfs,
+// so that udev naming rules do not matter.
+//
+// The devices are not opened: use Open with the Port of the result.
+func USBDevices() ([]USBDevice, error) {
+
+	ttys, err := filepath.Glob("/sys/class/tty/*/device")
+	if err != nil {
+		return nil, err
+	}
+
+	var list []USBDevice
+
+	for _, tty := range ttys {
+
+		dir, err := filepath.EvalSymlinks(tty)
+		if err != nil {
+			continue
+		}
+
+		// Walk up from the interface to the USB device node
+		for ; dir != "/" && dir != "."; dir = filepath.Dir(dir) {
+			if _, err := os.Stat(filepath.Join(dir, "idVendor")); err == nil {
+				break
+			}
+		}
+
+		vid := sysfsHex(dir, "idVendor")
+		pid := sysfsHex(dir, "idProduct")
+
+		if !isBitScopeUSB(vid, pid) {
+			continue
+		}
+
+		serial, _ := ioutil.ReadFile(filepath.Join(dir, "serial"))
+
+		list = append(list, USBDevice{
+			Port:   "/dev/" + filepath.Base(filepath.Dir(tty)),
+			VID:    vid,
+			PID:    pid,
+			Serial: strings.TrimSpace(string(serial)),
+		})
+	}
+
+	return list, nil
+}
+
+// sysfsHex reads a hexadecimal attribute of a sysfs node.
+func sysfsHex(dir, attr string) uint16 {
+	b, err := ioutil.ReadFile(filepath.Join(dir, attr))
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.ParseUint(strings.TrimSpace(string(b)), 16, 16)
+	return uint16(n)
+}
@@ -0,0 +1,17 @@
+// For the license see the LICENSE file (BSD style)
+
+//go:build !linux
+// +build !linux
+
+package bitscope
+
+import (
+	"errors"
+)
+
+// USBDevices returns the USB serial adapters with a BitScope VID/PID and
+// the tty nodes they are mapped to. Only Linux is supported: on other
+// systems use Scan.
+func USBDevices() ([]USBDevice, error) {
+	return nil, errors.New("USB enumeration not supported on this system")
+}
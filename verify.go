@@ -0,0 +1,59 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"bytes"
+	"context"
+	"errors"
+)
+
+// ErrCorruptDump is returned when the bytes of a dump differ from those
+// read again from the same buffer addresses, that is, when data has been
+// lost or altered on the serial link.
+var ErrCorruptDump = errors.New("Dump data corrupted")
+
+// SetVerifyDump enables the verification of dumps: after each chunk of a
+// dump, its last n bytes are read again and compared with those already
+// received. A difference makes Dump return ErrCorruptDump. Zero disables
+// the check.
+//
+// Lost bytes shift the rest of a chunk, so a small window at its end
+// detects them at a small cost in transfer time.
+func (bs *Scope) SetVerifyDump(n uint) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	bs.verify = n
+}
+
+// verifyDump dumps again the last bytes of data, which was read from the
+// given start address with opt, and compares them.
+func (bs *Scope) verifyDump(ctx context.Context, start uint, data []byte, opt DumpOption) error {
+
+	n := bs.verify
+	if n == 0 || len(data) == 0 {
+		return nil
+	}
+	if n > uint(len(data)) {
+		n = uint(len(data))
+	}
+	// The window starts at a group
+	off := uint(len(data)) - n
+	off -= off % opt.group()
+	n = uint(len(data)) - off
+
+	if err := bs.dumpSetup(start+opt.address(off), n, opt); err != nil {
+		return err
+	}
+
+	b, err := bs.callData(ctx, []byte("A"), n)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(b, data[off:]) {
+		return ErrCorruptDump
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+// For the license see the LICENSE file (BSD style)
+
+// Package vm builds command sequences for the virtual machine of the
+// BitScope BSNG instruments (BS05, BS10).
+//
+// The VM reads hex digits into an accumulator; '@' loads the address
+// register from it, 's' stores its low byte at the address, and 'z' stores
+// it and increments the address. Other letters are commands. A Program
+// takes care of this encoding:
+//
+//	cmd := vm.New().Reg(0x26).U16(pre).Reg(0x2a).U16(post).Trace().Bytes()
+//
+// which gives "26@LLzHHs2a@LLzHHsD", with the values in hex, least
+// significant byte first.
+package vm
+
+const hex = "0123456789abcdef"
+
+// Program is a sequence of VM commands under construction. The methods
+// append to it and return it, so that calls can be chained.
+type Program struct {
+	b []byte
+}
+
+// New returns an empty Program.
+func New() *Program {
+	return &Program{}
+}
+
+// Reg sets the address register, for the value that follows.
+func (p *Program) Reg(addr uint8) *Program {
+	p.hex(uint(addr))
+	p.b = append(p.b, '@')
+	return p
+}
+
+// U8 stores a byte at the current address.
+func (p *Program) U8(v uint) *Program {
+	return p.Uint(v, 1)
+}
+
+// U16 stores a 16 bit value at the current address and the next one.
+func (p *Program) U16(v uint) *Program {
+	return p.Uint(v, 2)
+}
+
+// U24 stores a 24 bit value from the current address on.
+func (p *Program) U24(v uint) *Program {
+	return p.Uint(v, 3)
+}
+
+// U32 stores a 32 bit value from the current address on.
+func (p *Program) U32(v uint) *Program {
+	return p.Uint(v, 4)
+}
+
+// Uint stores a value of size bytes (1 to 4) from the current address on,
+// least significant byte first. The address is left at the last byte.
+func (p *Program) Uint(v uint, size int) *Program {
+
+	if size < 1 {
+		size = 1
+	} else if size > 4 {
+		size = 4
+	}
+
+	for i := 0; i < size; i++ {
+		if i > 0 {
+			p.b = append(p.b, 'z')
+		}
+		p.hex(v >> (8 * uint(i)))
+	}
+	p.b = append(p.b, 's')
+	return p
+}
+
+// Set stores a value of size bytes at the given address. It is the same
+// as Reg(addr).Uint(v, size).
+func (p *Program) Set(addr uint8, v uint, size int) *Program {
+	return p.Reg(addr).Uint(v, size)
+}
+
+// Next increments the address register ('n').
+func (p *Program) Next() *Program {
+	return p.Cmd('n')
+}
+
+// Peek prints the byte at the current address as hex ('p').
+func (p *Program) Peek() *Program {
+	return p.Cmd('p')
+}
+
+// Trace starts a trace ('D').
+func (p *Program) Trace() *Program {
+	return p.Cmd('D')
+}
+
+// Dump dumps the capture buffer ('A').
+func (p *Program) Dump() *Program {
+	return p.Cmd('A')
+}
+
+// Cancel terminates a trace ('K').
+func (p *Program) Cancel() *Program {
+	return p.Cmd('K')
+}
+
+// Write stores a byte in the waveform memory of the generator, at the
+// address in AwgIndex, and increments it ('E').
+func (p *Program) Write(v uint) *Program {
+	p.hex(v)
+	return p.Cmd('E')
+}
+
+// Force triggers a trace that is waiting for its trigger event; the
+// post-trigger samples are then captured as usual ('T').
+func (p *Program) Force() *Program {
+	return p.Cmd('T')
+}
+
+// Update applies the register values to the hardware ('>').
+func (p *Program) Update() *Program {
+	return p.Cmd('>')
+}
+
+// Program commits the programmed registers before a trace ('U').
+func (p *Program) Program() *Program {
+	return p.Cmd('U')
+}
+
+// ID asks for the identification of the instrument ('?').
+func (p *Program) ID() *Program {
+	return p.Cmd('?')
+}
+
+// Reset does a soft reset of the instrument ('!').
+func (p *Program) Reset() *Program {
+	return p.Cmd('!')
+}
+
+// Stop terminates a command sequence ('.').
+func (p *Program) Stop() *Program {
+	return p.Cmd('.')
+}
+
+// Cmd appends raw command bytes.
+func (p *Program) Cmd(c ...byte) *Program {
+	p.b = append(p.b, c...)
+	return p
+}
+
+// Len returns the length of the program in bytes.
+func (p *Program) Len() int {
+	return len(p.b)
+}
+
+// Bytes returns the encoded program.
+func (p *Program) Bytes() []byte {
+	return p.b
+}
+
+// String returns the encoded program as a string.
+func (p *Program) String() string {
+	return string(p.b)
+}
+
+// hex appends the low byte of n as two hex digits.
+func (p *Program) hex(n uint) {
+	p.b = append(p.b, hex[(n>>4)&15], hex[n&15])
+}
@@ -0,0 +1,13 @@
+// For the license see the LICENSE file (BSD style)
+
+package vm
+
+import "fmt"
+
+func ExampleProgram() {
+	fmt.Println(New().Reg(0x26).U16(0x0102).Reg(0x2a).U16(0x0304).Trace())
+	fmt.Println(New().Set(0x22, 1000, 4).Update())
+	// Output:
+	// 26@02z01s2a@04z03sD
+	// 22@e8z03z00z00s>
+}
@@ -0,0 +1,118 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// EventKind tells whether a BitScope appeared or disappeared.
+type EventKind int
+
+const (
+	Attached EventKind = iota
+	Detached
+)
+
+// Event is sent by Watch when a BitScope is plugged in or removed.
+type Event struct {
+	Kind   EventKind
+	Device DeviceInfo
+}
+
+// pollInterval is the time between scans of the serial ports in Watch.
+const pollInterval = time.Second
+
+// Watch returns a channel on which an Event is sent each time a BitScope
+// appears or disappears. BitScopes present when Watch is called are
+// reported as attached first. The channel is closed when ctx is done.
+//
+// On Linux, device changes are signaled by the kernel (udev netlink
+// socket); on other systems, or if the socket is not available, the serial
+// ports are polled. New ports are probed as in Scan, so Watch should not
+// be started while a BitScope is in use by this program. Ports that cannot
+// be opened or do not answer are probed again at each scan, until they
+// answer with an ID; those of other devices are then skipped until they
+// disappear.
+func Watch(ctx context.Context) <-chan Event {
+
+	ch := make(chan Event)
+
+	go func() {
+		defer close(ch)
+
+		attached := make(map[string]DeviceInfo)
+		other := make(map[string]bool)
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		uevent := uevents(ctx)
+
+		for {
+			present := make(map[string]bool)
+
+			for _, port := range ports() {
+				present[port] = true
+
+				if _, ok := attached[port]; ok || other[port] {
+					continue
+				}
+
+				// A device that does not answer may be a BitScope
+				// still starting up, or busy: it is probed again at the
+				// next event or tick
+				bs, err := probe(port)
+				if err != nil {
+					var u unsupportedError
+					if errors.As(err, &u) && u.id != "" {
+						other[port] = true
+					}
+					continue
+				}
+
+				d := DeviceInfo{port, bs.ID, bs.Model}
+				bs.Close()
+				attached[port] = d
+
+				select {
+				case ch <- Event{Attached, d}:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			for port, d := range attached {
+				if present[port] {
+					continue
+				}
+				delete(attached, port)
+
+				select {
+				case ch <- Event{Detached, d}:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			for port := range other {
+				if !present[port] {
+					delete(other, port)
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-uevent:
+				// Give udev some time to create the device nodes
+				time.Sleep(100 * time.Millisecond)
+			}
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,60 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"bytes"
+	"context"
+	"syscall"
+)
+
+// uevents returns a channel that receives a value each time the kernel
+// reports a tty device being added or removed. If the netlink socket cannot
+// be opened, nil is returned and Watch falls back to polling.
+func uevents(ctx context.Context) <-chan struct{} {
+
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC,
+		syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil
+	}
+
+	err = syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: 1})
+	if err == nil {
+		// Wake up regularly to check if ctx is done
+		tv := syscall.Timeval{Sec: 1}
+		err = syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv)
+	}
+	if err != nil {
+		syscall.Close(fd)
+		return nil
+	}
+
+	ch := make(chan struct{}, 1)
+
+	go func() {
+		defer syscall.Close(fd)
+
+		b := make([]byte, 4096)
+
+		for ctx.Err() == nil {
+			n, _, err := syscall.Recvfrom(fd, b, 0)
+			if err == syscall.EAGAIN || err == syscall.EINTR {
+				continue
+			}
+			if err != nil {
+				return
+			}
+			if !bytes.Contains(b[:n], []byte("SUBSYSTEM=tty")) {
+				continue
+			}
+
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,15 @@
+// For the license see the LICENSE file (BSD style)
+
+//go:build !linux
+// +build !linux
+
+package bitscope
+
+import (
+	"context"
+)
+
+// uevents returns nil: device changes are found by polling.
+func uevents(ctx context.Context) <-chan struct{} {
+	return nil
+}
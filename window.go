@@ -0,0 +1,55 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import "errors"
+
+// TriggerWindow sets a window trigger on channel ch: the trigger fires when
+// the signal enters (enter true) or leaves the band between lo and hi
+// volts. Both comparator thresholds are used: TriggerLevel holds the lower
+// one and TriggerUpper the upper one, so the hysteresis set with
+// TriggerHysteresis is disabled. The vertical range must be set.
+//
+// Useful to catch supply rails going out of tolerance, for example.
+func (bs *Scope) TriggerWindow(ch uint, lo, hi float64, enter bool) error {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	i, err := channel(ch)
+	if err != nil {
+		return err
+	}
+	if bs.vrange == 0 {
+		return errors.New("Vertical range not set")
+	}
+	if lo >= hi {
+		return errors.New("Empty trigger window")
+	}
+
+	l := voltsToLevel(lo/bs.probe(i), bs.vrange)
+	h := voltsToLevel(hi/bs.probe(i), bs.vrange)
+
+	// Edge mode, hardware comparator, both thresholds (bit 1). The edge bit
+	// selects the inside (false -> true) or outside transition. The other
+	// bits, such as the inversion, are kept.
+	mode := bs.spock&^0x37 | 0x23
+	if !enter {
+		mode |= 0x10
+	}
+	if i == 1 {
+		mode |= 4
+	}
+
+	old := bs.hyst
+	bs.hyst = 0
+	if _, err = bs.set("triggerupper", reg(RegTriggerUpper, h)); err == nil {
+		if err = bs.trigger(ch, l); err == nil {
+			err = bs.triggerMode(mode)
+		}
+	}
+	if err != nil {
+		bs.hyst = old
+	}
+	return err
+}
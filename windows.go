@@ -0,0 +1,73 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import "context"
+
+// Windows acquires CHA repeatedly, for monitoring signals too slow for a
+// single trace (set a slow time base with Horizontal first). It captures
+// window samples without waiting for a trigger, dumps them, and sends them
+// on the returned channel, in order.
+//
+// This is not the roll mode of an oscilloscope: the windows are separate
+// captures, and the signal is not sampled while a window is dumped, so
+// there is a gap of at least the dump time between windows. Joining them
+// does not give a continuous record.
+//
+// The channel is closed when ctx is done, or after a Result with an error.
+// The trigger timeout is set to its minimum during Windows, and the
+// trigger timing restored before the channel is closed.
+func (bs *Scope) Windows(ctx context.Context, window uint) <-chan Result {
+
+	ch := make(chan Result, 1)
+
+	bs.mu.Lock()
+	t := bs.timing
+	bs.mu.Unlock()
+
+	go func() {
+		defer close(ch)
+		defer func() {
+			bs.mu.Lock()
+			bs.triggerTiming(t[0], t[1], t[2])
+			bs.mu.Unlock()
+		}()
+
+		for ctx.Err() == nil {
+
+			b, err := bs.window(ctx, window)
+			if ctx.Err() != nil {
+				return
+			}
+
+			select {
+			case ch <- Result{b, err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// window captures and dumps a window of samples. The Scope is locked for
+// one window at a time, so that other calls can be made during Windows.
+func (bs *Scope) window(ctx context.Context, window uint) ([]byte, error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	// Timeout after 1 tick, so that the trace does not wait for a trigger
+	if err := bs.triggerTiming(0, 0, 1); err != nil {
+		return nil, err
+	}
+
+	if _, err := bs.trace(ctx, 0, window, 0); err != nil {
+		return nil, err
+	}
+	return bs.dump(ctx, window)
+}
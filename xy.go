@@ -0,0 +1,133 @@
+// For the license see the LICENSE file (BSD style)
+
+package bitscope
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Point is a point of an XY plot, in the units of the traces (volts).
+type Point struct {
+	X, Y float64
+}
+
+// AcquireDual does a dual channel trace (see DualTrace) and dumps it, and
+// returns the samples of CHA and CHB as two traces at half the sample
+// rate, with cfg.Pre and cfg.Post samples per channel. CHB is sampled one
+// sample clock after CHA, that is half a sample of each trace later.
+func (bs *Scope) AcquireDual(ctx context.Context, cfg TraceConfig) (a, b *Trace, err error) {
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	size := cfg.Size
+	if size == 0 {
+		size = cfg.Pre + cfg.Post
+	}
+
+	r, err := bs.traceCapture(ctx, captureDual, 2*cfg.Pre, 2*cfg.Post, cfg.Delay)
+	if err != nil {
+		return nil, nil, err
+	}
+	t := time.Now()
+
+	d, err := bs.dump(ctx, 2*size)
+	if err != nil {
+		return nil, nil, err
+	}
+	da, db := deinterleave(d)
+
+	a = bs.newTrace(da, r, 2*cfg.Pre, t)
+	a.SampleRate /= 2
+	a.TriggerIndex /= 2
+
+	b = bs.newTrace(db, r, 2*cfg.Pre, t)
+	b.SampleRate, b.TriggerIndex = a.SampleRate, a.TriggerIndex
+	b.Channel, b.SoftwareAC, b.Probe = 'b', bs.softAC[1], bs.probe(1)
+	return a, b, nil
+}
+
+// XY pairs the samples of two traces taken at the same time, such as
+// those of AcquireDual, into the points of an XY plot: X from the first
+// trace and Y from the second. The number of points is that of the
+// shorter trace.
+func XY(x, y *Trace) ([]Point, error) {
+
+	if x.SampleRate != y.SampleRate || x.TriggerIndex != y.TriggerIndex {
+		return nil, errors.New("XY traces not sampled at the same times")
+	}
+	vx, vy := x.Volts(), y.Volts()
+	if vx == nil || vy == nil {
+		return nil, errors.New("Vertical range not set")
+	}
+
+	n := len(vx)
+	if len(vy) < n {
+		n = len(vy)
+	}
+	p := make([]Point, n)
+	for i := range p {
+		p[i] = Point{vx[i], vy[i]}
+	}
+	return p, nil
+}
+
+// Persistence accumulates the points of successive XY plots into a 2D
+// histogram, as the persistence of an analog scope screen. Cell (i, j)
+// covers the i-th of Width columns between XMin and XMax, and the j-th of
+// Height rows between YMin and YMax.
+type Persistence struct {
+	Width, Height int
+	XMin, XMax    float64
+	YMin, YMax    float64
+	// Number of points in each cell, row by row from YMin
+	Counts []uint32
+}
+
+// NewPersistence returns an empty histogram of w by h cells over the given
+// ranges.
+func NewPersistence(w, h int, xmin, xmax, ymin, ymax float64) *Persistence {
+	return &Persistence{
+		Width: w, Height: h,
+		XMin: xmin, XMax: xmax,
+		YMin: ymin, YMax: ymax,
+		Counts: make([]uint32, w*h),
+	}
+}
+
+// Add accumulates the points, ignoring those outside of the ranges.
+func (p *Persistence) Add(pts []Point) {
+
+	sx := float64(p.Width) / (p.XMax - p.XMin)
+	sy := float64(p.Height) / (p.YMax - p.YMin)
+
+	for _, pt := range pts {
+		i := int((pt.X - p.XMin) * sx)
+		j := int((pt.Y - p.YMin) * sy)
+		// The upper limits belong to the last cells
+		if pt.X == p.XMax {
+			i = p.Width - 1
+		}
+		if pt.Y == p.YMax {
+			j = p.Height - 1
+		}
+		if pt.X < p.XMin || pt.Y < p.YMin || i >= p.Width || j >= p.Height {
+			continue
+		}
+		p.Counts[j*p.Width+i]++
+	}
+}
+
+// At returns the number of points accumulated in cell (i, j).
+func (p *Persistence) At(i, j int) uint32 {
+	return p.Counts[j*p.Width+i]
+}
+
+// Reset clears the histogram.
+func (p *Persistence) Reset() {
+	for i := range p.Counts {
+		p.Counts[i] = 0
+	}
+}